@@ -0,0 +1,72 @@
+package tea
+
+import (
+	"testing"
+)
+
+// Bench benchmarks a single node of a tree. The ancestors of the node are run
+// once as setup, outside of the benchmark timer. The node's test is then run
+// b.N times, each time as a fresh clone of the test loaded from the
+// environment produced by its ancestors. The After method of the node's test
+// is called after each run, and the After methods of its ancestors are called
+// once the benchmark completes, all outside of the benchmark timer.
+//
+// Since a *testing.B is not a *testing.T, the tests in the node's chain must
+// implement TB, and tests having cleanup must implement AfterTB.
+//
+// A benchmark reusing a tree that is written for a Go test might look like
+// this:
+//
+//	func BenchmarkHits(b *testing.B) {
+//		root := tea.New(&testStartServer{})
+//		hit := root.Child(&testHits{path: "/alice"})
+//		tea.Bench(b, hit)
+//	}
+func Bench(b *testing.B, tree *Tree) {
+	b.StopTimer()
+	t := testB{b}
+
+	fx := new(fixtures)
+	defer fx.closeAll()
+
+	nodes := tree.ancestry()
+	x := execNodes(t, nodes[:len(nodes)-1], fx.provide)
+	defer teardown(t, x.history)
+	if x.inapplicable != "" {
+		b.Skip(x.inapplicable)
+	}
+	if b.Failed() || b.Skipped() {
+		b.Fatalf("tea benchmark setup failed for %s", tree.ID())
+	}
+	var e *env
+	if tree.parent != nil {
+		var err error
+		if e, err = parentEnv(tree, x.envs); err != nil {
+			b.Fatalf("test plan failed: %s", err)
+		}
+	}
+	if !tree.guard.met(e) {
+		b.Skipf("tea not applicable: %s", tree.guard.desc)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		test := clone(tree.test)
+		if err := fx.provide(b, tree, test, e); err != nil {
+			b.Fatalf("test plan failed: %s", err)
+		}
+		if err := tree.loadTest(test, e); err != nil {
+			b.Fatalf("test plan failed: %s", err)
+		}
+
+		b.StartTimer()
+		runTest(t, tree, test)
+		b.StopTimer()
+
+		t.after(test)
+		if b.Failed() {
+			b.Logf("tea path: %s", tree.ID())
+			b.FailNow()
+		}
+	}
+}
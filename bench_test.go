@@ -0,0 +1,77 @@
+package tea
+
+import (
+	"flag"
+	"testing"
+)
+
+// teardownCount counts the number of times its AfterTB method is called.
+type teardownCount struct {
+	n *int
+}
+
+func (test *teardownCount) Run(t *testing.T) { test.RunTB(t) }
+
+func (test *teardownCount) RunTB(t testing.TB) {}
+
+func (test *teardownCount) AfterTB(t testing.TB) { *test.n++ }
+
+// benchmark runs f as testing.Benchmark would, but for only a few
+// iterations, since Bench stops the benchmark timer for most of each one.
+func benchmark(t *testing.T, f func(*testing.B)) testing.BenchmarkResult {
+	benchtime := flag.Lookup("test.benchtime").Value
+	prev := benchtime.String()
+	if err := benchtime.Set("10x"); err != nil {
+		t.Fatal(err)
+	}
+	defer benchtime.Set(prev)
+	return testing.Benchmark(f)
+}
+
+func TestBench(t *testing.T) {
+	t.Run("tears down ancestors once", func(t *testing.T) {
+		var n int
+		root := New(&saveX{X: 1})
+		counted := root.Child(&teardownCount{n: &n})
+		leaf := counted.Child(&checkX{expect: 1})
+
+		res := benchmark(t, func(b *testing.B) {
+			n = 0
+			Bench(b, leaf)
+		})
+		if res.N == 0 {
+			t.Fatalf("expected the benchmark to pass")
+		}
+		if n != 1 {
+			t.Errorf("expected ancestors to be torn down once, saw %d teardowns", n)
+		}
+	})
+
+	t.Run("roots are not loaded", func(t *testing.T) {
+		// as in Run, a root's load fields are left as they are.
+		root := New(&checkX{expect: 0})
+		if res := benchmark(t, func(b *testing.B) { Bench(b, root) }); res.N == 0 {
+			t.Errorf("expected the benchmark of a root having load fields to pass")
+		}
+	})
+
+	t.Run("failures stop the benchmark", func(t *testing.T) {
+		root := New(&saveX{X: 1})
+		leaf := root.Child(&checkX{expect: 2})
+		if res := benchmark(t, func(b *testing.B) { Bench(b, leaf) }); res.N != 0 {
+			t.Errorf("expected the benchmark of a failing node to fail")
+		}
+	})
+}
+
+func BenchmarkBench(b *testing.B) {
+	var n int
+	root := New(&saveX{X: 1})
+	counted := root.Child(&teardownCount{n: &n})
+	leaf := counted.Child(&checkX{expect: 1})
+
+	Bench(b, leaf)
+	if n != 1 {
+		b.Errorf("expected ancestors to be torn down once, saw %d teardowns", n)
+	}
+}
@@ -0,0 +1,86 @@
+package tea
+
+import (
+	"testing"
+	"time"
+)
+
+// Decorator wraps a test value with behaviour of its own, such as timing,
+// tracing or logging, to be performed around the test's Run method. A
+// decorator is given a test that has already been loaded from its
+// environment, and the fields of that test, not those of the decorator's
+// result, are saved once it has run. The After methods of the test are called
+// on the test itself, not on the decorator's result. So that messages about
+// a decorated test name the test and not the decorator, a decorator's result
+// should have a String method giving the name of the test it wraps, as the
+// results of Timing do.
+//
+// Nodes marked with XFail or Retry, and trees run outside of a *testing.T,
+// require that their tests implement TB; a decorator used with those nodes
+// must return a TB when given one.
+type Decorator func(Test) Test
+
+// Decorate adds decorators to a tree node, returning the node so that calls
+// may be chained onto Child. Decorators are inherited: every test is wrapped
+// with the decorators of its node and of all of that node's ancestors, so a
+// decorator added to the root of a tree applies to every test in the tree:
+//
+//	root := tea.New(&testStartServer{}).Decorate(tea.Timing)
+//
+// Decorators are applied in order, each wrapping the result of the last. A
+// node's own decorators are applied before those of its ancestors, so the
+// decorators of the root are outermost. The name of a node is that of its
+// undecorated test.
+func (t *Tree) Decorate(decorators ...Decorator) *Tree {
+	t.decorators = append(t.decorators, decorators...)
+	return t
+}
+
+// decorate wraps a test belonging to a tree node with the decorators of the
+// node and its ancestors.
+func (t *Tree) decorate(test Test) Test {
+	nodes := t.ancestry()
+	for i := len(nodes) - 1; i >= 0; i-- {
+		for _, d := range nodes[i].decorators {
+			test = d(test)
+		}
+	}
+	return test
+}
+
+// Timing is a Decorator that logs the time taken by the Run method of each
+// test.
+func Timing(test Test) Test {
+	if tb, ok := test.(TB); ok {
+		return &timedTB{timed{test: test}, tb}
+	}
+	return &timed{test: test}
+}
+
+type timed struct {
+	test Test
+}
+
+func (d *timed) String() string { return parseName(d.test) }
+
+func (d *timed) Run(t *testing.T) {
+	defer d.log(t, time.Now())
+	d.test.Run(t)
+}
+
+func (d *timed) log(t testing.TB, start time.Time) {
+	t.Logf("tea timing: %s took %v", parseName(d.test), time.Since(start))
+}
+
+// timedTB is a timed test whose wrapped test implements TB.
+type timedTB struct {
+	timed
+	tb TB
+}
+
+func (d *timedTB) Run(t *testing.T) { d.RunTB(t) }
+
+func (d *timedTB) RunTB(t testing.TB) {
+	defer d.log(t, time.Now())
+	d.tb.RunTB(t)
+}
@@ -0,0 +1,65 @@
+package tea
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// tag is a test that wraps another, recording its name in a log when run.
+type tag struct {
+	name string
+	log  *[]string
+	test Test
+}
+
+func (d *tag) String() string { return parseName(d.test) }
+
+func (d *tag) Run(t *testing.T) { d.RunTB(t) }
+
+func (d *tag) RunTB(t testing.TB) {
+	*d.log = append(*d.log, d.name+":"+parseName(d.test))
+	runTB(t, d.test)
+}
+
+func tagger(name string, log *[]string) Decorator {
+	return func(test Test) Test { return &tag{name: name, log: log, test: test} }
+}
+
+func TestDecorate(t *testing.T) {
+	t.Run("order and inheritance", func(t *testing.T) {
+		var log []string
+		root := New(&saveX{X: 1}).Decorate(tagger("outer", &log))
+		root.Child(&checkX{expect: 1}).Decorate(tagger("inner", &log))
+		new(runner).run(testT{t}, root)
+
+		assertLog(t, log, "outer:saveX", "outer:saveX", "outer:checkX", "inner:checkX")
+	})
+
+	t.Run("fields are saved and loaded", func(t *testing.T) {
+		var log []string
+		root := New(&saveX{X: 1}).Decorate(tagger("d", &log))
+		root.Child(&saveX{X: 2}).Child(&checkX{expect: 2})
+		root.Child(Fails(&checkX{expect: 3}))
+		root.Child(&checkX{expect: 3}).XFail("decorated tests fail as expected")
+		new(runner).run(testT{t}, root)
+	})
+
+	t.Run("names are not decorated", func(t *testing.T) {
+		root := New(&saveX{X: 1}).Decorate(Timing)
+		root.Child(&checkX{expect: 1})
+
+		var buf bytes.Buffer
+		if !Standalone(&buf, root) {
+			t.Fatalf("expected tree to pass:\n%s", buf.String())
+		}
+		for _, line := range []string{
+			"--- PASS: saveX/checkX ",
+			"tea timing: checkX took ",
+		} {
+			if !strings.Contains(buf.String(), line) {
+				t.Errorf("expected output to contain %q:\n%s", line, buf.String())
+			}
+		}
+	})
+}
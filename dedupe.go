@@ -0,0 +1,164 @@
+package tea
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// dedupe merges trees that begin with identical prefixes, yielding the trees
+// to be run in their place. Two nodes from different trees are identical if
+// their tests have the same type and equal field values, they are in
+// identical positions, and they have the same markers and no hooks. The
+// children of identical nodes become the children of a single merged node,
+// so the shared prefix is run once for all of the trees, with its descendants
+// replaying it as they would in any one tree. Nodes from the same tree are
+// never merged with one another, since a tree that runs the same test twice
+// does so deliberately. Trees containing join nodes are left as they are, as
+// are trees containing focused nodes, since focus applies to a whole tree and
+// would otherwise leave out the nodes of the trees merged with it.
+//
+// The trees given to dedupe are not modified; merged trees are built from
+// copies of their nodes.
+func dedupe(trees []*Tree) ([]*Tree, savings) {
+	var (
+		merged []*Tree
+		origin = make(map[*Tree]map[int]bool)
+		s      = savings{trees: make(map[*Tree]bool)}
+	)
+	for i, tree := range trees {
+		s.before += cost(tree)
+		if hasJoins(tree) || len(findFocused(tree)) > 0 {
+			merged = append(merged, tree)
+			continue
+		}
+
+		var into *Tree
+		for _, m := range merged {
+			if origin[m] != nil && !origin[m][i] && identical(m, tree) {
+				into = m
+				break
+			}
+		}
+		if into == nil {
+			into = copyNode(tree, nil)
+			origin[into] = map[int]bool{}
+			merged = append(merged, into)
+		} else {
+			s.nodes++
+			s.trees[into] = true
+		}
+		origin[into][i] = true
+		mergeChildren(into, tree, i, origin, &s)
+	}
+
+	for _, tree := range merged {
+		s.after += cost(tree)
+	}
+	return merged, s
+}
+
+// mergeChildren merges the children of src, a node of the tree having index
+// i, into dst.
+func mergeChildren(dst, src *Tree, i int, origin map[*Tree]map[int]bool, s *savings) {
+	for _, child := range src.children {
+		var into *Tree
+		for _, c := range dst.children {
+			if !origin[c][i] && identical(c, child) {
+				into = c
+				break
+			}
+		}
+		if into == nil {
+			into = copyNode(child, dst)
+			origin[into] = map[int]bool{}
+		} else {
+			s.nodes++
+		}
+		origin[into][i] = true
+		mergeChildren(into, child, i, origin, s)
+	}
+}
+
+// copyNode copies a tree node without its children, adding the copy as a
+// child of parent if parent is not nil.
+func copyNode(t *Tree, parent *Tree) *Tree {
+	node := *t
+	node.parent = parent
+	node.children = nil
+	node.copyMarkers()
+	node.copyFixtures()
+	if parent != nil {
+		parent.children = append(parent.children, &node)
+	}
+	return &node
+}
+
+// identical checks whether two tree nodes may be merged.
+func identical(a, b *Tree) bool {
+	if reflect.TypeOf(a.test) != reflect.TypeOf(b.test) || !reflect.DeepEqual(a.test, b.test) {
+		return false
+	}
+	if a.name != b.name || !reflect.DeepEqual(a.labels, b.labels) {
+		return false
+	}
+	if a.focused != b.focused || a.pending != b.pending || a.failFast != b.failFast {
+		return false
+	}
+	if !reflect.DeepEqual(a.xfail, b.xfail) || !reflect.DeepEqual(a.retry, b.retry) {
+		return false
+	}
+	return plain(a) && plain(b)
+}
+
+// plain checks whether a tree node has none of the markers holding functions,
+// which cannot be compared.
+func plain(t *Tree) bool {
+	return t.guard == nil && len(t.invariants) == 0 && len(t.decorators) == 0 &&
+		len(t.fixtures) == 0 && len(t.hooks.beforeEach) == 0 && len(t.hooks.afterEach) == 0 &&
+		len(t.hooks.beforeSubtree) == 0 && len(t.hooks.afterSubtree) == 0
+}
+
+// hasJoins checks whether any node of a tree is a join node.
+func hasJoins(tree *Tree) bool {
+	found := false
+	tree.Walk(func(node *Tree) bool {
+		found = found || len(node.parents) > 0
+		return !found
+	})
+	return found
+}
+
+// cost counts the test runs needed to run every node of a tree, each node
+// replaying its ancestors.
+func cost(tree *Tree) int {
+	n := 0
+	tree.Walk(func(node *Tree) bool {
+		n += len(node.ancestry())
+		return true
+	})
+	return n
+}
+
+// savings describes the merging of trees by dedupe.
+type savings struct {
+	// trees is the set of merged trees having more than one source tree.
+	trees map[*Tree]bool
+
+	// nodes is the number of nodes merged into another node.
+	nodes int
+
+	// before and after are the number of test runs needed to run every
+	// node of the trees, before and after merging.
+	before int
+	after  int
+}
+
+// write writes a description of the savings to w.
+func (s savings) write(w io.Writer) {
+	if s.nodes == 0 {
+		return
+	}
+	fmt.Fprintf(w, "tea: merged %d shared %s into %d %s: %d test runs instead of %d, each node still replaying its ancestors\n",
+		s.nodes, plural(s.nodes, "node"), len(s.trees), plural(len(s.trees), "tree"), s.after, s.before)
+}
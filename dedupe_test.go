@@ -0,0 +1,76 @@
+package tea
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDedupe(t *testing.T) {
+	var log []string
+	first := New(&record{name: "A", log: &log})
+	first.Child(&record{name: "B", log: &log}).Child(&record{name: "C", log: &log})
+	second := New(&record{name: "A", log: &log})
+	second.Child(&record{name: "B", log: &log}).Child(&record{name: "D", log: &log})
+	second.Child(&record{name: "B", log: &log}).Child(&record{name: "E", log: &log})
+	third := New(&record{name: "X", log: &log})
+	third.Child(&record{name: "Y", log: &log})
+
+	trees, saved := dedupe([]*Tree{first, second, third})
+	if len(trees) != 2 {
+		t.Fatalf("expected 2 trees after merging, saw %d", len(trees))
+	}
+
+	t.Run("merged tree", func(t *testing.T) {
+		var ids []string
+		trees[0].Walk(func(node *Tree) bool {
+			ids = append(ids, node.ID())
+			return true
+		})
+		assertLog(t, ids, "A", "A/B", "A/B/C", "A/B/D", "A/B[1]", "A/B[1]/E")
+
+		log = nil
+		new(runner).run(testT{t}, trees[0])
+		assertLog(t, log, "A", "A", "B", "A", "B", "C", "A", "B", "D", "A", "B", "A", "B", "E")
+	})
+
+	t.Run("originals are unchanged", func(t *testing.T) {
+		if first.Len() != 3 || second.Len() != 5 {
+			t.Errorf("expected merging to leave the original trees as they were")
+		}
+	})
+
+	t.Run("savings", func(t *testing.T) {
+		var buf bytes.Buffer
+		saved.write(&buf)
+		want := "tea: merged 2 shared nodes into 1 tree: 17 test runs instead of 20, each node still replaying its ancestors\n"
+		if out := buf.String(); out != want {
+			t.Errorf("unexpected savings: %q", out)
+		}
+	})
+
+	t.Run("different values are not merged", func(t *testing.T) {
+		trees, saved := dedupe([]*Tree{New(&saveX{X: 1}), New(&saveX{X: 2})})
+		if len(trees) != 2 || saved.nodes != 0 {
+			t.Errorf("expected trees with different root values to be left apart")
+		}
+	})
+
+	t.Run("focused trees are not merged", func(t *testing.T) {
+		log = nil
+		focused := New(&record{name: "A", log: &log})
+		focused.Child(&record{name: "B", log: &log}).Focus()
+		other := New(&record{name: "A", log: &log})
+		other.Child(&record{name: "C", log: &log})
+
+		trees, saved := dedupe([]*Tree{focused, other})
+		if len(trees) != 2 || saved.nodes != 0 {
+			t.Fatalf("expected a tree having focused nodes to be left apart")
+		}
+		for _, tree := range trees {
+			r := new(runner)
+			r.setFocus(t, tree)
+			r.run(testT{t}, tree)
+		}
+		assertLog(t, log, "A", "A", "B", "A", "A", "C")
+	})
+}
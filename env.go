@@ -47,7 +47,7 @@ func mkenv(test Test) *env {
 // save tag. All of the fields for that tests are stored together as a data
 // layer.
 func (e *env) save(test Test) *env {
-	V := reflect.ValueOf(test)
+	V := reflect.ValueOf(subject(test))
 	if V.Type().Kind() == reflect.Ptr {
 		V = V.Elem()
 	}
@@ -77,7 +77,58 @@ func (e *env) save(test Test) *env {
 	return e
 }
 
+// id identifies the data of an environment layer. Layers that are copied
+// from one environment to another, as they are by merge and match, share
+// their data and therefore have the same id.
+func (e *env) id() uintptr {
+	return reflect.ValueOf(e.data).Pointer()
+}
+
+// merge merges two environments, yielding an environment having the layers of
+// e followed by the layers of other that are not shared with e. Layers are
+// shared by environments produced by a common ancestor. It is an error for a
+// field to be saved in a layer of each environment that is not shared.
+func (e *env) merge(other *env) (*env, error) {
+	mine := make(map[uintptr]bool)
+	for l := e; l != nil; l = l.parent {
+		mine[l.id()] = true
+	}
+	theirs := make(map[uintptr]bool)
+	for l := other; l != nil; l = l.parent {
+		theirs[l.id()] = true
+	}
+
+	saved := make(map[string]bool)
+	for l := e; l != nil; l = l.parent {
+		if theirs[l.id()] {
+			continue
+		}
+		for k := range l.data {
+			saved[k] = true
+		}
+	}
+
+	var layers []*env
+	for l := other; l != nil; l = l.parent {
+		if mine[l.id()] {
+			continue
+		}
+		for k := range l.data {
+			if saved[k] {
+				return nil, fmt.Errorf("%w: field %q is saved by more than one parent", PlanError, k)
+			}
+		}
+		layers = append(layers, l)
+	}
+
+	for i := len(layers) - 1; i >= 0; i-- {
+		e = &env{data: layers[i].data, parent: e}
+	}
+	return e, nil
+}
+
 func (e *env) load(dest Test) error {
+	dest = subject(dest)
 	destV := reflect.ValueOf(dest).Elem()
 	destT := destV.Type()
 
@@ -0,0 +1,97 @@
+package tea
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// Eventually wraps a test, yielding a test that runs the wrapped test
+// repeatedly until it passes or the timeout expires, waiting for interval
+// between attempts. Eventually is intended for asserting on asynchronous
+// systems, where the effect of a step becomes visible only after some time,
+// e.g.:
+//
+//	root.Child(tea.Eventually(&testHits{path: "/alice", hits: 1}, time.Second, 10*time.Millisecond))
+//
+// Each attempt is run against an isolated testing.TB with a fresh copy of the
+// wrapped test, as loaded from the environment, so an attempt does not see
+// the changes made by an earlier attempt. Each attempt that is followed by
+// another is cleaned up before the next attempt is run. Only the attempt that
+// passes is reported; if the timeout expires, the last failed attempt is
+// reported. The wrapped test's fields are saved and loaded as if it were not
+// wrapped, with saved fields taken from the attempt that passed, and the
+// cleanup of that attempt is performed as if it were not wrapped.
+func Eventually(test TB, timeout, interval time.Duration) Test {
+	return &eventually{test: test, timeout: timeout, interval: interval}
+}
+
+// Condition creates a test having the provided name that runs the function
+// f. Condition lets a plain function be passed to Eventually, where a
+// dedicated test type would be more ceremony than it is worth:
+//
+//	root.Child(tea.Eventually(tea.Condition("queue drained", func(t testing.TB) {
+//		if n := queue.Len(); n != 0 {
+//			t.Errorf("queue has %d items", n)
+//		}
+//	}), time.Second, 10*time.Millisecond))
+func Condition(name string, f func(testing.TB)) TB {
+	return &condition{name: name, f: f}
+}
+
+type condition struct {
+	name string
+	f    func(testing.TB)
+}
+
+func (c *condition) String() string { return c.name }
+
+func (c *condition) Run(t *testing.T) { c.RunTB(t) }
+
+func (c *condition) RunTB(t testing.TB) { c.f(t) }
+
+type eventually struct {
+	test     TB
+	timeout  time.Duration
+	interval time.Duration
+}
+
+func (e *eventually) String() string { return fmt.Sprintf("Eventually(%s)", parseName(e.test)) }
+
+func (e *eventually) Run(t *testing.T) { e.RunTB(t) }
+
+func (e *eventually) RunTB(t testing.TB) {
+	loaded := e.test
+	deadline := time.Now().Add(e.timeout)
+	for attempt := 1; ; attempt++ {
+		test := clone(loaded).(TB)
+		rec := capture(t, test.RunTB)
+		if rec.Skipped() {
+			e.test = test
+			rec.replay(t)
+			t.SkipNow()
+		}
+		if !rec.Failed() {
+			e.test = test
+			rec.replay(t)
+			if attempt > 1 {
+				t.Logf("tea condition met after %d attempts", attempt)
+			}
+			return
+		}
+		if !time.Now().Add(e.interval).Before(deadline) {
+			e.test = test
+			rec.replay(t)
+			t.Errorf("tea condition not met within %v after %d attempts", e.timeout, attempt)
+			return
+		}
+		afterAny(t, test)
+		time.Sleep(e.interval)
+	}
+}
+
+func (e *eventually) unwrap() Test { return e.test }
+
+func (e *eventually) rewrap(test Test) Test {
+	return &eventually{test: test.(TB), timeout: e.timeout, interval: e.interval}
+}
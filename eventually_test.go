@@ -0,0 +1,96 @@
+package tea
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+// countdown fails until it has been run a number of times, saving the number
+// of runs it took to pass.
+type countdown struct {
+	Runs    int `tea:"save"`
+	passAt  int
+	counter *int
+}
+
+func (test *countdown) Run(t *testing.T) { test.RunTB(t) }
+
+func (test *countdown) RunTB(t testing.TB) {
+	*test.counter++
+	test.Runs = *test.counter
+	if test.Runs < test.passAt {
+		t.Errorf("not yet: run %d", test.Runs)
+	}
+}
+
+// cleanedCountdown is a countdown having cleanup, counting its calls to
+// After.
+type cleanedCountdown struct {
+	countdown
+	cleanups *int
+}
+
+func (test *cleanedCountdown) After(t *testing.T) { *test.cleanups++ }
+
+func TestEventually(t *testing.T) {
+	t.Run("name", func(t *testing.T) {
+		if name := parseName(Eventually(&checkX{}, time.Second, time.Millisecond)); name != "Eventually(checkX)" {
+			t.Errorf("unexpected name for eventually test: %q", name)
+		}
+		if name := parseName(Condition("queue drained", nil)); name != "queue drained" {
+			t.Errorf("unexpected name for condition: %q", name)
+		}
+	})
+
+	t.Run("passes once the condition is met", func(t *testing.T) {
+		var counter int
+		root := New(&saveX{X: 1})
+		root.Child(Eventually(&countdown{passAt: 3, counter: &counter}, time.Second, time.Millisecond)).
+			Child(&checkRuns{expect: 4})
+		new(runner).run(testT{t}, root)
+	})
+
+	t.Run("loads fields into the wrapped test", func(t *testing.T) {
+		root := New(&saveX{X: 1})
+		root.Child(Eventually(&checkX{expect: 1}, time.Second, time.Millisecond))
+		new(runner).run(testT{t}, root)
+	})
+
+	t.Run("reports the last failure on timeout", func(t *testing.T) {
+		var counter int
+		test := Eventually(&countdown{passAt: 1 << 30, counter: &counter}, 20*time.Millisecond, time.Millisecond)
+		rec := capture(t, test.(TB).RunTB)
+		if !rec.Failed() {
+			t.Fatalf("expected Eventually to fail when its condition is never met")
+		}
+		last := rec.logs[len(rec.logs)-2]
+		if !regexp.MustCompile(`eventually_test\.go:\d+: not yet: run `).MatchString(last) {
+			t.Errorf("expected the last failure to be reported, saw %q", last)
+		}
+		if n := len(rec.failures); n != 1 {
+			t.Errorf("expected 1 failure, saw %d: %v", n, rec.failures)
+		}
+	})
+
+	t.Run("conditions", func(t *testing.T) {
+		var n int
+		test := Eventually(Condition("third time lucky", func(t testing.TB) {
+			if n++; n < 3 {
+				t.Error("unlucky")
+			}
+		}), time.Second, time.Millisecond)
+		if rec := capture(t, test.(TB).RunTB); rec.Failed() {
+			t.Errorf("expected condition to be met: %v", rec.failures)
+		}
+	})
+	t.Run("cleans up every attempt", func(t *testing.T) {
+		var counter, cleanups int
+		root := New(&saveX{X: 1})
+		root.Child(Eventually(&cleanedCountdown{countdown: countdown{passAt: 3, counter: &counter}, cleanups: &cleanups}, time.Second, time.Millisecond))
+		new(runner).run(testT{t}, root)
+		if cleanups != 3 {
+			t.Errorf("expected 2 failed attempts and the passing attempt to be cleaned up, saw %d cleanups", cleanups)
+		}
+	})
+}
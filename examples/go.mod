@@ -6,3 +6,5 @@ require (
 	github.com/jordanorelli/tea v0.0.5-0.20200731133129-a3346f59ccfc
 	github.com/smartystreets/goconvey v1.6.4
 )
+
+replace github.com/jordanorelli/tea => ../
@@ -35,7 +35,12 @@ type testHits struct {
 	hits int
 }
 
-func (test *testHits) Run(t *testing.T) {
+func (test *testHits) Run(t *testing.T) { test.RunTB(t) }
+
+// RunTB satisfies the tea.TB interface, which allows testHits to be run
+// against an isolated testing.TB, e.g. when wrapped with tea.Fails to check
+// that a request does not see a given number of hits.
+func (test *testHits) RunTB(t testing.TB) {
 	client := test.Server.Client()
 
 	res, err := client.Get(test.Server.URL + test.path)
@@ -83,5 +88,10 @@ func TestServer(t *testing.T) {
 	two.Child(bob)
 	root.Child(bob)
 
+	// a test can also be reused to check that something does not happen:
+	// wrapped with tea.Fails, a test passes only if it fails. Since /alice
+	// has been hit twice by now, a third hit is not the first.
+	two.Child(tea.Fails(&testHits{path: "/alice", hits: 1}))
+
 	tea.Run(t, root)
 }
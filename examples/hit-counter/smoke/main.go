@@ -0,0 +1,134 @@
+// Command smoke runs a smoke test against a running hit-counter server, such
+// as the one in the parent directory, outside of go test. It serves as an
+// example of running a tree of tea tests from a normal binary with
+// tea.Standalone.
+//
+// Usage:
+//
+//	smoke [-tea.path=<id>] [addr]
+//
+// addr is the base URL of the server, http://127.0.0.1:54321 by default.
+// smoke exits with a status of 0 if every test passes, 1 if any test fails,
+// and 2 if it is invoked incorrectly.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/jordanorelli/tea"
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] [addr]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	addr := "http://127.0.0.1:54321"
+	switch flag.NArg() {
+	case 0:
+	case 1:
+		addr = flag.Arg(0)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if !tea.Standalone(os.Stdout, smokeTest(addr)) {
+		os.Exit(1)
+	}
+}
+
+// smokeTest builds the tree of tests to be run against the server at addr.
+func smokeTest(addr string) *tea.Tree {
+	root := tea.New(&testConnect{Addr: addr})
+	first := root.Child(&testHit{path: "/tea-smoke"})
+	second := first.Child(&testRepeatHit{path: "/tea-smoke"})
+	second.Child(&testRepeatHit{path: "/tea-smoke"})
+	root.Child(&testHit{path: "/tea-smoke-other"})
+	return root
+}
+
+// response is the body of a response from a hit-counter server.
+type response struct {
+	OK   bool `json:"ok"`
+	Hits int  `json:"hits"`
+}
+
+// testConnect checks that the server can be reached, saving its address for
+// future tests.
+type testConnect struct {
+	Addr string `tea:"save"`
+}
+
+func (test *testConnect) Run(t *testing.T) { test.RunTB(t) }
+
+func (test *testConnect) RunTB(t testing.TB) {
+	res, err := http.Get(test.Addr + "/")
+	if err != nil {
+		t.Fatalf("unable to reach server at %s: %v", test.Addr, err)
+	}
+	res.Body.Close()
+	t.Logf("connected to server at %s", test.Addr)
+}
+
+// testHit requests a path on the server, saving the number of hits it saw.
+// Since the server may be shared, we can't know how many hits a path will
+// have, only that it has at least one.
+type testHit struct {
+	Addr string `tea:"load"`
+	Hits int    `tea:"save"`
+
+	path string
+}
+
+func (test *testHit) Run(t *testing.T) { test.RunTB(t) }
+
+func (test *testHit) RunTB(t testing.TB) {
+	test.Hits = hit(t, test.Addr+test.path)
+	if test.Hits < 1 {
+		t.Errorf("expected %s to have at least 1 hit, saw %d instead", test.path, test.Hits)
+	}
+}
+
+// testRepeatHit requests a path that was requested by a previous test,
+// checking that it sees exactly one more hit than the previous test did.
+type testRepeatHit struct {
+	Addr string `tea:"load"`
+	Hits int    `tea:"load,save"`
+
+	path string
+}
+
+func (test *testRepeatHit) Run(t *testing.T) { test.RunTB(t) }
+
+func (test *testRepeatHit) RunTB(t testing.TB) {
+	hits := hit(t, test.Addr+test.path)
+	if hits != test.Hits+1 {
+		t.Errorf("expected %s to have %d hits, saw %d instead", test.path, test.Hits+1, hits)
+	}
+	test.Hits = hits
+}
+
+// hit requests a url from a hit-counter server, returning the number of hits
+// that the server reports.
+func hit(t testing.TB, url string) int {
+	res, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("request to %s failed: %v", url, err)
+	}
+	defer res.Body.Close()
+
+	var body response
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		t.Fatalf("response at %s was not json: %v", url, err)
+	}
+	t.Logf("%s has %d hits", url, body.Hits)
+	return body.Hits
+}
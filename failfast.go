@@ -0,0 +1,57 @@
+package tea
+
+import (
+	"sync"
+)
+
+// FailFast marks a tree to be run in fail-fast mode, returning the node so
+// that calls may be chained. FailFast may be called on any node of a tree,
+// but applies to the entire tree. Fail-fast mode is also enabled for every
+// tree by the -tea.failfast flag.
+//
+// In fail-fast mode, once any node fails, no more nodes of the tree are run.
+// The failed node's chain still has its After methods called, but every node
+// not yet run is reported as skipped, with a reason naming the node that
+// failed first.
+func (t *Tree) FailFast() *Tree {
+	t.root().failFast = true
+	return t
+}
+
+// setFailFast configures the runner for fail-fast mode if it is enabled by
+// the tea.failfast flag or on the tree.
+func (r *runner) setFailFast(tree *Tree) {
+	r.failFast = *failFastFlag || tree.root().failFast
+}
+
+// firstFail records the path ID of the first node to fail in a run. It may be
+// shared by the runners of several trees, as it is by RunRegistered in
+// fail-fast mode, so that the first failure in any tree stops them all, even
+// when they are run in parallel.
+type firstFail struct {
+	mu sync.Mutex
+	id string
+}
+
+// firstFailure returns the path ID of the first node to fail, or an empty
+// string if no node has failed.
+func (r *runner) firstFailure() string {
+	if r.failure == nil {
+		return ""
+	}
+	r.failure.mu.Lock()
+	defer r.failure.mu.Unlock()
+	return r.failure.id
+}
+
+// fail records the failure of a tree node, if no node has failed before it.
+func (r *runner) fail(tree *Tree) {
+	if r.failure == nil {
+		r.failure = new(firstFail)
+	}
+	r.failure.mu.Lock()
+	defer r.failure.mu.Unlock()
+	if r.failure.id == "" {
+		r.failure.id = tree.ID()
+	}
+}
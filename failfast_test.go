@@ -0,0 +1,29 @@
+package tea
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestFailFast(t *testing.T) {
+	root := New(&saveX{X: 1}).FailFast()
+	root.Child(&checkX{expect: 2}).Child(&checkX{expect: 1})
+	root.Child(&checkX{expect: 1})
+
+	var buf bytes.Buffer
+	if Standalone(&buf, root) {
+		t.Fatalf("expected tree to fail:\n%s", buf.String())
+	}
+	out := buf.String()
+	for _, line := range []string{
+		"--- FAIL: saveX/checkX ",
+		"--- SKIP: saveX/checkX/checkX",
+		"--- SKIP: saveX/checkX#01",
+		"tea skipped: fail-fast after the failure of saveX/checkX",
+	} {
+		if !strings.Contains(out, line) {
+			t.Errorf("expected output to contain %q:\n%s", line, out)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package tea
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// Fails wraps a test, yielding a test that passes only if the wrapped test
+// fails. Fails lets us reuse a positive test type to assert that an
+// operation is rejected in a given state, e.g.:
+//
+//	// with no prior requests, /alice has not been hit twice.
+//	root.Child(tea.Fails(&testHits{path: "/alice", hits: 2}))
+//
+// The wrapped test is run against an isolated testing.TB, so its failures are
+// logged but do not fail the Go test that is running it. The wrapped test's
+// fields are saved and loaded as if it were not wrapped.
+func Fails(test TB) Test {
+	return &fails{test: test}
+}
+
+// FailsWith is like Fails, except that the wrapped test must also report a
+// failure having a message that matches the regular expression pattern.
+// FailsWith panics if the pattern cannot be compiled.
+func FailsWith(test TB, pattern string) Test {
+	return &fails{test: test, pattern: regexp.MustCompile(pattern)}
+}
+
+type fails struct {
+	test    TB
+	pattern *regexp.Regexp
+}
+
+func (f *fails) String() string { return fmt.Sprintf("Fails(%s)", parseName(f.test)) }
+
+func (f *fails) Run(t *testing.T) { f.RunTB(t) }
+
+func (f *fails) RunTB(t testing.TB) {
+	rec := capture(t, f.test.RunTB)
+	rec.replay(t)
+
+	if rec.Skipped() {
+		t.SkipNow()
+	}
+	if !rec.Failed() {
+		t.Errorf("expected %s to fail but it passed", parseName(f.test))
+		return
+	}
+	if f.pattern == nil {
+		return
+	}
+	for _, msg := range rec.failures {
+		if f.pattern.MatchString(msg) {
+			return
+		}
+	}
+	t.Errorf("expected %s to fail with a message matching %q, but it failed with: %s",
+		parseName(f.test), f.pattern, strings.Join(rec.failures, "; "))
+}
+
+func (f *fails) unwrap() Test { return f.test }
+
+func (f *fails) rewrap(test Test) Test {
+	return &fails{test: test.(TB), pattern: f.pattern}
+}
@@ -0,0 +1,91 @@
+package tea
+
+import (
+	"testing"
+)
+
+type saveX struct {
+	X int `tea:"save"`
+}
+
+func (test *saveX) Run(t *testing.T) { test.RunTB(t) }
+
+func (test *saveX) RunTB(t testing.TB) {}
+
+type checkX struct {
+	X      int `tea:"load"`
+	expect int
+}
+
+func (test *checkX) Run(t *testing.T) { test.RunTB(t) }
+
+func (test *checkX) RunTB(t testing.TB) {
+	if test.X != test.expect {
+		t.Errorf("expected X to be %d, is %d instead", test.expect, test.X)
+	}
+}
+
+// cleanedCheckX is a checkX having cleanup, counting its calls to After.
+type cleanedCheckX struct {
+	checkX
+	cleanups *int
+}
+
+func (test *cleanedCheckX) After(t *testing.T) { *test.cleanups++ }
+
+func TestFails(t *testing.T) {
+	t.Run("name", func(t *testing.T) {
+		if name := parseName(Fails(&checkX{})); name != "Fails(checkX)" {
+			t.Errorf("unexpected name for negative test: %q", name)
+		}
+	})
+
+	t.Run("wrapped tests load fields", func(t *testing.T) {
+		root := New(&saveX{X: 1})
+		root.Child(Fails(&checkX{expect: 2}))
+		root.Child(FailsWith(&checkX{expect: 3}, `is 1 instead`))
+		new(runner).run(testT{t}, root)
+	})
+
+	t.Run("passing wrapped test fails", func(t *testing.T) {
+		e := mkenv(&saveX{X: 1})
+		test := clone(Fails(&checkX{expect: 1}))
+		if err := e.load(test); err != nil {
+			t.Fatalf("unexpected load error: %v", err)
+		}
+		rec := capture(t, test.(TB).RunTB)
+		if !rec.Failed() {
+			t.Errorf("expected Fails to fail when its wrapped test passes")
+		}
+	})
+
+	t.Run("unmatched failure message fails", func(t *testing.T) {
+		e := mkenv(&saveX{X: 1})
+		test := clone(FailsWith(&checkX{expect: 2}, `wrong message`))
+		if err := e.load(test); err != nil {
+			t.Fatalf("unexpected load error: %v", err)
+		}
+		rec := capture(t, test.(TB).RunTB)
+		if !rec.Failed() {
+			t.Errorf("expected FailsWith to fail when the failure message does not match")
+		}
+	})
+
+	t.Run("clones do not share wrapped tests", func(t *testing.T) {
+		original := Fails(&checkX{expect: 2})
+		copied := clone(original)
+		copied.(*fails).test.(*checkX).X = 5
+		if original.(*fails).test.(*checkX).X != 0 {
+			t.Errorf("cloning a wrapper did not clone its wrapped test")
+		}
+	})
+	t.Run("wrapped tests are cleaned up", func(t *testing.T) {
+		var cleanups int
+		root := New(&saveX{X: 1})
+		root.Child(Fails(&cleanedCheckX{checkX: checkX{expect: 2}, cleanups: &cleanups}))
+		new(runner).run(testT{t}, root)
+		if cleanups != 1 {
+			t.Errorf("expected the wrapped test's After method to be called once, saw %d calls", cleanups)
+		}
+	})
+}
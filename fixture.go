@@ -0,0 +1,295 @@
+package tea
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// Fixture registers a shared fixture on a tree node, returning the node so
+// that calls may be chained onto Child. A fixture is an expensive resource,
+// such as a server or a temporary database file, that is shared by the node
+// and its descendants instead of being created by a test in each path.
+//
+// Tests depend on a fixture just as they depend on the saved fields of their
+// ancestors: a field tagged with tea:"load" whose name is the name of a
+// fixture, and for which no ancestor has saved a value, is loaded with the
+// fixture's value:
+//
+//	root := tea.New(&testMigrate{}).Fixture("DB", func(t testing.TB) (interface{}, func()) {
+//		db := openTempDB(t)
+//		return db, func() { db.Close() }
+//	})
+//
+//	type testInsert struct {
+//		DB *sql.DB `tea:"load"`
+//	}
+//
+// setup is called the first time a test loads the fixture in a call to Run,
+// in the testing context of the node being run, and returns the fixture's
+// value along with a function to tear it down, which may be nil. Since the
+// fixture may outlive that node, setup should release its resources in the
+// teardown function rather than with the Cleanup method of its testing.TB,
+// which releases them when the node finishes. Every test that loads the
+// fixture after that is given the same value. The fixture is torn down once
+// every node in its subtree that loads it and is run has finished along with
+// its own subtree, and no later than when the node it is registered on, or
+// the call to Run, has finished. A fixture registered on a node shadows a
+// fixture of the same name registered on any of its ancestors. A grafted copy
+// of a node has its own copy of the node's fixtures.
+//
+// If setup fails, every test loading the fixture fails with a RunError.
+func (t *Tree) Fixture(name string, setup func(testing.TB) (interface{}, func())) *Tree {
+	t.fixtures = append(t.fixtures, &fixture{name: name, owner: t, setup: setup})
+	return t
+}
+
+// fixture is a shared fixture registered on a tree node, or with
+// RegisterFixture, in which case it has no owner.
+type fixture struct {
+	name  string
+	owner *Tree
+	setup func(testing.TB) (interface{}, func())
+}
+
+// copyFixtures gives a copy of a tree node its own copies of the fixtures
+// registered on the original, so that they are set up and torn down with the
+// copy rather than with the original.
+func (t *Tree) copyFixtures() {
+	original := t.fixtures
+	t.fixtures = nil
+	for _, fx := range original {
+		t.fixtures = append(t.fixtures, &fixture{name: fx.name, owner: t, setup: fx.setup})
+	}
+}
+
+// lookupFixture finds the fixture having the provided name that is visible
+// from a tree node.
+func (t *Tree) lookupFixture(name string) *fixture {
+	nodes := t.ancestry()
+	for i := len(nodes) - 1; i >= 0; i-- {
+		for _, fx := range nodes[i].fixtures {
+			if fx.name == name {
+				return fx
+			}
+		}
+	}
+	return nil
+}
+
+// fixtures is the set of fixtures that have been set up in a run, along with
+// the nodes still depending on each. A fixtures may be shared by the runners
+// of several trees, as it is by RunRegistered, in which case it also holds
+// the fixtures registered with RegisterFixture that are visible to those
+// trees.
+type fixtures struct {
+	global []*fixture
+
+	// trees are the trees being run. Only the nodes of those trees for which
+	// visits is true are counted as dependents of a fixture, since no other
+	// node will be run to release it. If trees is empty, or visits is nil,
+	// dependents are not restricted by it.
+	trees  []*Tree
+	visits func(*Tree) bool
+
+	mu   sync.Mutex
+	live map[*fixture]*liveFixture
+}
+
+// liveFixture is a fixture that has been set up, or is being set up.
+type liveFixture struct {
+	value    interface{}
+	err      error
+	teardown func()
+
+	// ready is closed once the fixture has been set up.
+	ready chan struct{}
+
+	// closed is true if the fixture was closed while it was being set up, in
+	// which case it is torn down as soon as its setup returns.
+	closed bool
+
+	// dependents is the set of nodes that load the fixture and have not yet
+	// finished.
+	dependents map[*Tree]bool
+}
+
+// provide sets the load fields of a test that are to be loaded with the value
+// of a fixture, setting up the fixture if it is not live. A field is loaded
+// from a fixture only if no value for it is found in the environment e. The
+// test belongs to the provided tree node, which determines the fixtures that
+// are visible to it.
+func (f *fixtures) provide(t testing.TB, node *Tree, test Test, e *env) error {
+	V := reflect.ValueOf(subject(test))
+	if V.Type().Kind() == reflect.Ptr {
+		V = V.Elem()
+	}
+	if V.Type().Kind() != reflect.Struct {
+		return nil
+	}
+	T := V.Type()
+
+	for i := 0; i < T.NumField(); i++ {
+		field := T.Field(i)
+		if !isLoadField(field) || !V.Field(i).IsZero() {
+			continue
+		}
+		if _, ok := (Env{e}).Lookup(field.Name); ok {
+			continue
+		}
+		fx := node.lookupFixture(field.Name)
+		if fx == nil {
+			fx = f.lookupGlobal(field.Name)
+		}
+		if fx == nil {
+			continue
+		}
+
+		v, err := f.get(t, fx)
+		if err != nil {
+			return err
+		}
+		fv := reflect.ValueOf(v)
+		if !fv.IsValid() || !fv.Type().AssignableTo(field.Type) {
+			return fmt.Errorf("%w: fixture %q has type %T, which cannot be loaded into a field of type %s", PlanError, fx.name, v, field.Type)
+		}
+		V.Field(i).Set(fv)
+	}
+	return nil
+}
+
+// get gets the value of a fixture, setting it up if it is not live. If the
+// fixture is being set up by another test, get waits for it to be set up. The
+// fixture is set up without holding f.mu, so that slow setups do not hold up
+// other fixtures.
+func (f *fixtures) get(t testing.TB, fx *fixture) (interface{}, error) {
+	f.mu.Lock()
+	if f.live == nil {
+		f.live = make(map[*fixture]*liveFixture)
+	}
+	if l, ok := f.live[fx]; ok {
+		f.mu.Unlock()
+		<-l.ready
+		return l.value, l.err
+	}
+
+	l := &liveFixture{ready: make(chan struct{}), dependents: make(map[*Tree]bool)}
+	dependents := func(node *Tree) bool {
+		if loads(node.test, fx.name) && f.runs(node) {
+			l.dependents[node] = true
+		}
+		return true
+	}
+	if fx.owner != nil {
+		fx.owner.Walk(dependents)
+	} else {
+		for _, tree := range f.trees {
+			tree.Walk(dependents)
+		}
+	}
+	f.live[fx] = l
+	f.mu.Unlock()
+
+	rec := capture(t, func(t testing.TB) { l.value, l.teardown = fx.setup(t) })
+	rec.replay(t)
+	if rec.Failed() || rec.Skipped() {
+		l.err = fmt.Errorf("%w: fixture %q failed to set up", RunError, fx.name)
+	}
+
+	f.mu.Lock()
+	close(l.ready)
+	closed := l.closed
+	f.mu.Unlock()
+	if closed && l.teardown != nil {
+		l.teardown()
+	}
+	return l.value, l.err
+}
+
+// runs checks whether a tree node belongs to one of the trees being run and
+// will be run itself.
+func (f *fixtures) runs(node *Tree) bool {
+	if f.visits != nil && !f.visits(node) {
+		return false
+	}
+	if len(f.trees) == 0 {
+		return true
+	}
+	for _, ancestor := range node.ancestry() {
+		for _, tree := range f.trees {
+			if ancestor == tree {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// release records that a tree node and all of its descendants have finished,
+// tearing down the fixtures having no remaining dependents and the fixtures
+// registered on those nodes.
+func (f *fixtures) release(tree *Tree) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.live) == 0 {
+		return
+	}
+	tree.Walk(func(node *Tree) bool {
+		for fx, l := range f.live {
+			delete(l.dependents, node)
+			if len(l.dependents) == 0 || fx.owner == node {
+				f.close(fx)
+			}
+		}
+		return true
+	})
+}
+
+// close tears down a live fixture. A fixture that is still being set up is
+// instead torn down by get once its setup returns. The caller must hold f.mu.
+func (f *fixtures) close(fx *fixture) {
+	l := f.live[fx]
+	delete(f.live, fx)
+	select {
+	case <-l.ready:
+		if l.teardown != nil {
+			l.teardown()
+		}
+	default:
+		l.closed = true
+	}
+}
+
+// closeAll tears down every live fixture.
+func (f *fixtures) closeAll() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for fx := range f.live {
+		f.close(fx)
+	}
+}
+
+// lookupGlobal finds the fixture registered with RegisterFixture having the
+// provided name.
+func (f *fixtures) lookupGlobal(name string) *fixture {
+	for _, fx := range f.global {
+		if fx.name == name {
+			return fx
+		}
+	}
+	return nil
+}
+
+// loads checks whether a test has a load field having the provided name.
+func loads(test Test, name string) bool {
+	T := reflect.TypeOf(subject(test))
+	if T.Kind() == reflect.Ptr {
+		T = T.Elem()
+	}
+	if T.Kind() != reflect.Struct {
+		return false
+	}
+	field, ok := T.FieldByName(name)
+	return ok && isLoadField(field)
+}
@@ -0,0 +1,160 @@
+package tea
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// useCounter loads a counter fixture, incrementing it when run.
+type useCounter struct {
+	Counter *int `tea:"load"`
+	name    string
+	log     *[]string
+}
+
+func (test *useCounter) String() string { return test.name }
+
+func (test *useCounter) Run(t *testing.T) { test.RunTB(t) }
+
+func (test *useCounter) RunTB(t testing.TB) {
+	*test.Counter++
+	*test.log = append(*test.log, test.name)
+}
+
+// saveCounter saves a counter of its own.
+type saveCounter struct {
+	Counter *int `tea:"save"`
+}
+
+func (test *saveCounter) Run(t *testing.T) { test.RunTB(t) }
+
+func (test *saveCounter) RunTB(t testing.TB) {}
+
+func TestFixture(t *testing.T) {
+	var (
+		log     []string
+		counter int
+	)
+	counterFixture := func(t testing.TB) (interface{}, func()) {
+		log = append(log, "setup")
+		counter = 0
+		return &counter, func() { log = append(log, "teardown") }
+	}
+
+	t.Run("shared and torn down after the last dependent", func(t *testing.T) {
+		log = nil
+		root := New(&record{name: "root", log: &log}).Fixture("Counter", counterFixture)
+		root.Child(&useCounter{name: "A", log: &log}).Child(&useCounter{name: "B", log: &log})
+		root.Child(&record{name: "C", log: &log}).Child(&useCounter{name: "D", log: &log})
+		root.Child(&record{name: "E", log: &log})
+		new(runner).run(testT{t}, root)
+
+		assertLog(t, log,
+			"root",
+			"root", "setup", "A",
+			"root", "A", "B",
+			"root", "C",
+			"root", "C", "D", "teardown",
+			"root", "E",
+		)
+		if counter != 4 {
+			t.Errorf("expected every dependent to share the fixture, counted %d runs", counter)
+		}
+	})
+
+	t.Run("saved values take precedence", func(t *testing.T) {
+		log = nil
+		var saved int
+		root := New(&saveCounter{Counter: &saved}).Fixture("Counter", counterFixture)
+		root.Child(&useCounter{name: "A", log: &log})
+		new(runner).run(testT{t}, root)
+
+		assertLog(t, log, "A")
+		if saved != 1 {
+			t.Errorf("expected the saved counter to be loaded, counted %d runs", saved)
+		}
+	})
+
+	t.Run("failed setup", func(t *testing.T) {
+		log = nil
+		root := New(&saveX{X: 1}).Fixture("Counter", func(t testing.TB) (interface{}, func()) {
+			t.Fatal("no counters today")
+			return nil, nil
+		})
+		root.Child(&useCounter{name: "A", log: &log})
+
+		var buf bytes.Buffer
+		if Standalone(&buf, root) {
+			t.Fatalf("expected tree to fail:\n%s", buf.String())
+		}
+		for _, line := range []string{
+			"no counters today",
+			`test run error: fixture "Counter" failed to set up`,
+		} {
+			if !strings.Contains(buf.String(), line) {
+				t.Errorf("expected output to contain %q:\n%s", line, buf.String())
+			}
+		}
+	})
+	t.Run("torn down when the run ends", func(t *testing.T) {
+		log = nil
+		root := New(&record{name: "root", log: &log}).Fixture("Counter", counterFixture)
+		a := root.Child(&useCounter{name: "A", log: &log})
+		root.Child(&useCounter{name: "E", log: &log})
+		Run(t, a)
+
+		assertLog(t, log, "root", "setup", "A", "teardown")
+	})
+
+	t.Run("grafted copies own their fixtures", func(t *testing.T) {
+		log = nil
+		template := New(&record{name: "T", log: &log}).Fixture("Counter", counterFixture)
+		template.Child(&useCounter{name: "A", log: &log})
+		root := New(&record{name: "root", log: &log})
+		root.Graft(template)
+		root.Child(&record{name: "C", log: &log}).Graft(template)
+		new(runner).run(testT{t}, root)
+
+		assertLog(t, log,
+			"root",
+			"root", "T",
+			"root", "T", "setup", "A", "teardown",
+			"root", "C",
+			"root", "C", "T",
+			"root", "C", "T", "setup", "A", "teardown",
+		)
+	})
+
+	t.Run("set up without holding the lock", func(t *testing.T) {
+		started := make(chan struct{})
+		release := make(chan struct{})
+		slow := &fixture{name: "Slow", setup: func(testing.TB) (interface{}, func()) {
+			close(started)
+			<-release
+			return 1, nil
+		}}
+		fast := &fixture{name: "Fast", setup: func(testing.TB) (interface{}, func()) {
+			return 2, nil
+		}}
+
+		f := new(fixtures)
+		values := make(chan interface{}, 2)
+		for i := 0; i < 2; i++ {
+			go func() {
+				v, _ := f.get(t, slow)
+				values <- v
+			}()
+		}
+		<-started
+		if v, _ := f.get(t, fast); v != 2 {
+			t.Errorf("expected to get a fixture while another is set up, got %v", v)
+		}
+		close(release)
+		for i := 0; i < 2; i++ {
+			if v := <-values; v != 1 {
+				t.Errorf("expected both tests to get the slow fixture once it is set up, got %v", v)
+			}
+		}
+	})
+}
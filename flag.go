@@ -0,0 +1,21 @@
+package tea
+
+import (
+	"flag"
+)
+
+// command-line flags understood by tea. These are registered on the default
+// flag set, so they are parsed by go test alongside the standard -test.*
+// flags, e.g.:
+//
+//	go test -run TestServer -tea.path=testStartServer/testHits[1]
+var (
+	pathFlag = flag.String("tea.path", "", "run only the tea node having this path ID and its ancestors. A trailing /... also runs its descendants.")
+
+	labelsFlag        = flag.String("tea.labels", "", "comma-separated list of labels. If set, only tea nodes having one of these labels and their ancestors are run.")
+	excludeLabelsFlag = flag.String("tea.exclude-labels", "", "comma-separated list of labels. tea nodes having any of these labels are not run.")
+	failFastFlag      = flag.Bool("tea.failfast", false, "stop running tea nodes after the first failure.")
+
+	profileFlag = flag.String("tea.profile", "", "write a profile of the time spent running each tea node, and replaying it as an ancestor of other nodes, to this file.")
+	traceFlag   = flag.String("tea.trace", "", "write a timeline of tea node runs to this file, in Chrome's trace event format.")
+)
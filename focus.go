@@ -0,0 +1,76 @@
+package tea
+
+import (
+	"os"
+	"testing"
+)
+
+// Focus marks a tree node as focused, returning the node so that calls may be
+// chained onto Child. If any node in a tree given to Run is focused, Run only
+// runs focused nodes, along with their ancestors and descendants; every other
+// node is reported as skipped.
+//
+// Focus is intended as an aid when developing a tree and should not be left
+// in committed code. If the TEA_FORBID_FOCUS environment variable is set to a
+// non-empty value, as it might be in a CI environment, Run fails on finding
+// a focused node and ignores the focus, running the entire tree.
+func (t *Tree) Focus() *Tree {
+	t.focused = true
+	return t
+}
+
+// Pending marks a tree node as pending, returning the node so that calls may
+// be chained onto Child. A pending node and all of its descendants are
+// reported as skipped without being run.
+func (t *Tree) Pending() *Tree {
+	t.pending = true
+	return t
+}
+
+// setFocus configures the runner to run only focused nodes if any node in the
+// tree is focused. It is an error to have focused nodes when
+// TEA_FORBID_FOCUS is set.
+func (r *runner) setFocus(t testing.TB, tree *Tree) {
+	focused := findFocused(tree)
+	if len(focused) == 0 {
+		return
+	}
+	if os.Getenv("TEA_FORBID_FOCUS") != "" {
+		for _, node := range focused {
+			t.Errorf("tea focus is forbidden by TEA_FORBID_FOCUS but node is focused: %s", node.ID())
+		}
+		return
+	}
+	r.focus = true
+}
+
+// unfocused checks whether a tree node is left out of a run because other
+// nodes in the tree are focused, returning a reason to be used when reporting
+// the node as skipped.
+func (r *runner) unfocused(tree *Tree) string {
+	if !r.focus {
+		return ""
+	}
+	for _, node := range tree.ancestry() {
+		if node.focused {
+			return ""
+		}
+	}
+	if len(findFocused(tree)) > 0 {
+		return ""
+	}
+	return "tea skipped: not focused"
+}
+
+// findFocused finds all of the focused nodes in a tree, including the root of
+// the tree.
+func findFocused(tree *Tree) []*Tree {
+	var focused []*Tree
+	if tree.focused {
+		focused = append(focused, tree)
+	}
+	for _, child := range tree.children {
+		focused = append(focused, findFocused(child)...)
+	}
+	return focused
+}
@@ -0,0 +1,58 @@
+package tea
+
+import (
+	"testing"
+)
+
+func TestFocus(t *testing.T) {
+	var log []string
+	root := New(&record{name: "A", log: &log})
+	b := root.Child(&record{name: "B", log: &log})
+	b.Child(&record{name: "C", log: &log}).Focus()
+	b.Child(&record{name: "D", log: &log})
+	e := root.Child(&record{name: "E", log: &log}).Focus()
+	e.Child(&record{name: "F", log: &log})
+	root.Child(&record{name: "G", log: &log})
+
+	r := new(runner)
+	r.setFocus(t, root)
+	if !r.focus {
+		t.Fatalf("expected runner to be focused")
+	}
+	r.run(testT{t}, root)
+	assertLog(t, log, "A", "A", "B", "A", "B", "C", "A", "E", "A", "E", "F")
+}
+
+func TestForbidFocus(t *testing.T) {
+	defer setenv("TEA_FORBID_FOCUS", "1")()
+
+	var log []string
+	root := New(&record{name: "A", log: &log})
+	b := root.Child(&record{name: "B", log: &log})
+	b.Child(&record{name: "C", log: &log}).Focus()
+	b.Child(&record{name: "D", log: &log})
+	root.Child(&record{name: "E", log: &log})
+
+	r := new(runner)
+	errs := new(errorLog)
+	r.setFocus(errs, root)
+	if len(errs.errors) != 1 {
+		t.Errorf("expected a focused node to fail the run, saw errors %q", errs.errors)
+	}
+	if r.focus {
+		t.Errorf("expected runner to ignore the focus")
+	}
+	r.run(testT{t}, root)
+	assertLog(t, log, "A", "A", "B", "A", "B", "C", "A", "B", "D", "A", "E")
+}
+
+func TestPending(t *testing.T) {
+	var log []string
+	root := New(&record{name: "A", log: &log})
+	b := root.Child(&record{name: "B", log: &log}).Pending()
+	b.Child(&record{name: "C", log: &log})
+	root.Child(&record{name: "D", log: &log})
+
+	new(runner).run(testT{t}, root)
+	assertLog(t, log, "A", "A", "D")
+}
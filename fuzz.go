@@ -0,0 +1,141 @@
+//go:build go1.18
+// +build go1.18
+
+package tea
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Fuzz drives a path of a tree from go test -fuzz. The path runs from the
+// root of the tree to the provided node. Fields of the tests along the path
+// that are marked with a fuzz tag are populated by the inputs generated by the
+// fuzzing engine, and the current values of those fields are added to the
+// seed corpus. For each input, the path is run as a chain, with every
+// ancestor of the node running as setup, just as Run would run them:
+//
+//	type testCreateUser struct {
+//		Name string `tea:"fuzz,save"`
+//	}
+//
+//	func FuzzServer(f *testing.F) {
+//		root := tea.New(&testStartServer{})
+//		create := root.Child(&testCreateUser{Name: "alice"})
+//		tea.Fuzz(f, create.Child(&testGetUser{}))
+//	}
+//
+// Fuzz fields must have a type supported by the fuzzing engine, such as
+// string, []byte, bool or any of the numeric types. Failures and crashes are
+// reported along with the path ID of the node.
+func Fuzz(f *testing.F, tree *Tree) {
+	fields, seeds, err := findFuzzFields(tree)
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(seeds...)
+
+	params := []reflect.Type{reflect.TypeOf((*testing.T)(nil))}
+	for _, seed := range seeds {
+		params = append(params, reflect.TypeOf(seed))
+	}
+	fn := reflect.MakeFunc(reflect.FuncOf(params, nil, false), func(args []reflect.Value) []reflect.Value {
+		runFuzzInput(args[0].Interface().(*testing.T), tree, fields, args[1:])
+		return nil
+	})
+	f.Fuzz(fn.Interface())
+}
+
+// findFuzzFields finds the fuzz fields of the tests on the path to a tree
+// node, returning the fields of each node along with the current values of
+// every field, in the order in which they are given as fuzz inputs.
+func findFuzzFields(tree *Tree) (map[*Tree][]fuzzField, []interface{}, error) {
+	fields := make(map[*Tree][]fuzzField)
+	var seeds []interface{}
+	for _, node := range tree.ancestry() {
+		V := reflect.ValueOf(subject(node.test)).Elem()
+		T := V.Type()
+		for i := 0; i < T.NumField(); i++ {
+			field := T.Field(i)
+			if !isFuzzField(field) {
+				continue
+			}
+			if !fuzzable(field.Type) {
+				return nil, nil, fmt.Errorf("%w: field %s of %s has type %s, which cannot be fuzzed", PlanError, field.Name, node.name, field.Type)
+			}
+			fields[node] = append(fields[node], fuzzField{index: i, input: len(seeds)})
+			seeds = append(seeds, V.Field(i).Interface())
+		}
+	}
+	if len(seeds) == 0 {
+		return nil, nil, fmt.Errorf("%w: no fuzz fields found on the path to %s", PlanError, tree.ID())
+	}
+	return fields, seeds, nil
+}
+
+// runFuzzInput runs the path to a tree node for a single fuzz input, setting
+// the fuzz fields of each test from inputs.
+func runFuzzInput(t *testing.T, tree *Tree, fields map[*Tree][]fuzzField, inputs []reflect.Value) {
+	id := tree.ID()
+	defer func() {
+		if p := recover(); p != nil {
+			t.Errorf("tea path: %s panicked", id)
+			panic(p)
+		}
+	}()
+
+	fx := new(fixtures)
+	defer fx.closeAll()
+	x := execNodes(testT{t}, tree.ancestry(), func(t testing.TB, node *Tree, test Test, e *env) error {
+		V := reflect.ValueOf(subject(test)).Elem()
+		for _, field := range fields[node] {
+			V.Field(field.index).Set(inputs[field.input])
+		}
+		return fx.provide(t, node, test, e)
+	})
+	teardown(testT{t}, x.history)
+	if x.inapplicable != "" {
+		t.Skip(x.inapplicable)
+	}
+	if t.Failed() {
+		t.Logf("tea path: %s", id)
+	}
+}
+
+// fuzzField identifies a fuzz field of a test by the index of the field in the
+// test's struct type and the index of its value in the fuzz inputs.
+type fuzzField struct {
+	index int
+	input int
+}
+
+// isFuzzField takes a struct field and checks its tags for a fuzz tag,
+// indicating that the field's value should be provided by the fuzzing engine.
+func isFuzzField(f reflect.StructField) bool {
+	// PkgPath is empty string when the identifier is unexported.
+	if f.PkgPath != "" {
+		return false
+	}
+	parts := strings.Split(f.Tag.Get("tea"), ",")
+	for _, part := range parts {
+		if part == "fuzz" {
+			return true
+		}
+	}
+	return false
+}
+
+// fuzzable checks whether a type is supported by the fuzzing engine.
+func fuzzable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Bool, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return t.PkgPath() == ""
+	case reflect.Slice:
+		return t == reflect.TypeOf([]byte(nil))
+	}
+	return false
+}
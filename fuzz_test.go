@@ -0,0 +1,78 @@
+//go:build go1.18
+// +build go1.18
+
+package tea
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+type fuzzX struct {
+	X int `tea:"fuzz,save"`
+}
+
+func (test *fuzzX) Run(t *testing.T) {}
+
+type fuzzY struct {
+	X int    `tea:"load"`
+	Y string `tea:"fuzz"`
+}
+
+func (test *fuzzY) Run(t *testing.T) {
+	if len(test.Y) > 0 && test.X == 0 {
+		t.Logf("fuzzing with X = 0 and Y = %q", test.Y)
+	}
+}
+
+func FuzzPath(f *testing.F) {
+	root := New(&fuzzX{X: 1})
+	Fuzz(f, root.Child(&fuzzY{Y: "hello"}))
+}
+
+func TestFuzzable(t *testing.T) {
+	type role string
+	if !fuzzable(reflect.TypeOf("")) || !fuzzable(reflect.TypeOf([]byte(nil))) {
+		t.Errorf("expected string and []byte to be fuzzable")
+	}
+	if fuzzable(reflect.TypeOf(role(""))) || fuzzable(reflect.TypeOf([]string(nil))) {
+		t.Errorf("expected named types and non-byte slices not to be fuzzable")
+	}
+}
+
+// fuzzLog records the values its fields were loaded with.
+type fuzzLog struct {
+	X   int    `tea:"load"`
+	Y   string `tea:"fuzz"`
+	log *[]string
+}
+
+func (test *fuzzLog) Run(t *testing.T) {
+	*test.log = append(*test.log, fmt.Sprintf("X=%d Y=%q", test.X, test.Y))
+}
+
+func TestFuzzInput(t *testing.T) {
+	var log []string
+	root := New(&fuzzX{X: 1})
+	leaf := root.Child(&record{name: "setup", log: &log}).Child(&fuzzLog{Y: "hello", log: &log})
+
+	fields, seeds, err := findFuzzFields(leaf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(seeds, []interface{}{1, "hello"}) {
+		t.Errorf("expected the current field values as seeds, saw %v", seeds)
+	}
+
+	runFuzzInput(t, leaf, fields, []reflect.Value{reflect.ValueOf(7), reflect.ValueOf("fuzzed")})
+	assertLog(t, log, "setup", `X=7 Y="fuzzed"`)
+
+	if _, _, err := findFuzzFields(root.Child(&record{name: "plain", log: &log})); err != nil {
+		t.Errorf("expected the root's fuzz field to be found, saw %v", err)
+	}
+	if _, _, err := findFuzzFields(New(&record{name: "plain", log: &log})); !errors.Is(err, PlanError) {
+		t.Errorf("expected a path without fuzz fields to be a plan error, saw %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package tea
+
+// Generator is an optional interface for Test values that discover more tests
+// to run while they are running. If a Test value implements Generator and
+// passes, its Generate method is called after its Run method, and each of the
+// returned tests is run as a child of the test's node, after its other
+// children, e.g.:
+//
+//	// testList lists the items on the server, and checks each of them.
+//	type testList struct {
+//		Server *httptest.Server `tea:"load"`
+//		items  []string
+//	}
+//
+//	func (test *testList) Generate() []tea.Test {
+//		tests := make([]tea.Test, len(test.items))
+//		for i, item := range test.items {
+//			tests[i] = &testGetItem{id: item}
+//		}
+//		return tests
+//	}
+//
+// Generated children are run just like any other children: they load the
+// fields saved by their ancestors, which are run again for each generated
+// child, and they are named by their tests with path IDs following those of
+// their siblings. Generated children exist only for the duration of a run;
+// they are not added to the tree.
+type Generator interface {
+	Generate() []Test
+}
+
+// generate creates the nodes for the tests generated by a run of a node's
+// test, if the test is a Generator.
+func (t *Tree) generate(test Test) []*Tree {
+	g, ok := subject(test).(Generator)
+	if !ok {
+		return nil
+	}
+
+	tests := g.Generate()
+	nodes := make([]*Tree, 0, len(tests))
+	for _, test := range tests {
+		node := New(test)
+		node.parent = t
+		node.generated = true
+		node.ordinal = t.countNamed(node.name, nil)
+		for _, sibling := range nodes {
+			if sibling.name == node.name {
+				node.ordinal++
+			}
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
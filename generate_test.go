@@ -0,0 +1,57 @@
+package tea
+
+import (
+	"fmt"
+	"testing"
+)
+
+// list saves a count of items, and generates a check for each of them.
+type list struct {
+	Items int `tea:"save"`
+	log   *[]string
+}
+
+func (test *list) Run(t *testing.T) {
+	*test.log = append(*test.log, "list")
+}
+
+func (test *list) Generate() []Test {
+	tests := make([]Test, test.Items)
+	for i := range tests {
+		tests[i] = &item{n: i, log: test.log}
+	}
+	return tests
+}
+
+type item struct {
+	Items int `tea:"load"`
+	n     int
+	log   *[]string
+}
+
+func (test *item) Run(t *testing.T) {
+	*test.log = append(*test.log, fmt.Sprintf("item %d of %d", test.n, test.Items))
+}
+
+func TestGenerate(t *testing.T) {
+	var log []string
+	root := New(&list{Items: 2, log: &log})
+	root.Child(&item{n: -1, log: &log})
+
+	r := new(runner)
+	r.run(testT{t}, root)
+	assertLog(t, log, "list", "list", "item -1 of 2", "list", "item 0 of 2", "list", "item 1 of 2")
+	if len(root.children) != 1 {
+		t.Errorf("expected generated children not to be added to the tree")
+	}
+
+	generated := root.generate(&list{Items: 2, log: &log})
+	if generated[0].ID() != "list/item[1]" || generated[1].ID() != "list/item[2]" {
+		t.Errorf("unexpected IDs for generated nodes: %s, %s", generated[0].ID(), generated[1].ID())
+	}
+
+	log = nil
+	r.setPath("list/item[2]")
+	r.run(testT{t}, root)
+	assertLog(t, log, "list", "list", "item 1 of 2")
+}
@@ -0,0 +1,121 @@
+package tea
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// Graft adds a copy of a subtree as a child of the current tree node,
+// returning the copy of the subtree's root. Graft lets us define a subtree of
+// tests once, typically as a detached tree created by New, and graft it
+// under as many parents as we like, instead of calling Child for every node
+// of the subtree under every parent:
+//
+//	checks := tea.New(&testHits{path: "/alice", hits: 1})
+//	checks.Child(&testHits{path: "/alice", hits: 2})
+//
+//	root := tea.New(&testStartServer{})
+//	root.Graft(checks)
+//	root.Child(&testHits{path: "/bob", hits: 1}).Graft(checks)
+//
+// Every node of the subtree is copied, along with its test value, its markers
+// such as labels and its fixtures, so each graft is independent of the
+// template and of every other graft. A join node within the subtree is
+// joined to the copies of its parents that are within the subtree, and to the
+// same nodes as the template for parents that are outside of it.
+//
+// Each of the overrides is called with the copy of every test in the
+// subtree, before the test's name is determined, letting each graft
+// parameterize the template. Override creates an override that sets a
+// field by name.
+func (t *Tree) Graft(template *Tree, overrides ...func(Test)) *Tree {
+	copies := make(map[*Tree]*Tree)
+	root := template.graft(t, copies, overrides)
+	for original, node := range copies {
+		if len(original.parents) == 0 {
+			continue
+		}
+		node.parents = make([]*Tree, len(original.parents))
+		for i, parent := range original.parents {
+			if c, ok := copies[parent]; ok {
+				node.parents[i] = c
+			} else {
+				node.parents[i] = parent
+			}
+		}
+	}
+	return root
+}
+
+// graft copies a tree node and its descendants as a child of parent,
+// recording the copy of each node in copies.
+func (t *Tree) graft(parent *Tree, copies map[*Tree]*Tree, overrides []func(Test)) *Tree {
+	test := t.test
+	if reflect.ValueOf(test).Kind() == reflect.Ptr {
+		test = clone(test)
+	}
+	for _, override := range overrides {
+		override(test)
+	}
+
+	node := *t
+	node.test = test
+	node.name = paramName(test, t.params)
+	node.parent = parent
+	node.parents = nil
+	node.children = nil
+	node.copyMarkers()
+	node.copyFixtures()
+	parent.children = append(parent.children, &node)
+	copies[t] = &node
+
+	for _, child := range t.children {
+		child.graft(&node, copies, overrides)
+	}
+	return &node
+}
+
+// Override creates an override for Graft that sets the exported field having
+// the provided name to value, for every grafted test having such a field.
+// Tests without such a field are left as they are. The override panics if the
+// field is unexported or value is not assignable to it.
+func Override(field string, value interface{}) func(Test) {
+	return func(test Test) {
+		V := reflect.ValueOf(subject(test))
+		if V.Kind() != reflect.Ptr || V.Elem().Kind() != reflect.Struct {
+			return
+		}
+		f := V.Elem().FieldByName(field)
+		if !f.IsValid() {
+			return
+		}
+		if !f.CanSet() {
+			panic(fmt.Sprintf("tea: cannot override unexported field %s of %s", field, parseName(test)))
+		}
+		if value == nil {
+			f.Set(reflect.Zero(f.Type()))
+			return
+		}
+		v := reflect.ValueOf(value)
+		if !v.Type().AssignableTo(f.Type()) {
+			panic(fmt.Sprintf("tea: cannot override field %s of %s having type %s with a value of type %s", field, parseName(test), f.Type(), v.Type()))
+		}
+		f.Set(v)
+	}
+}
+
+// copyMarkers gives a copy of a tree node copies of the slices holding its
+// markers, so that markers added to the copy are not added to the original,
+// nor to any other copy.
+func (t *Tree) copyMarkers() {
+	t.labels = append([]string(nil), t.labels...)
+	t.invariants = append([]invariant(nil), t.invariants...)
+	t.decorators = append([]Decorator(nil), t.decorators...)
+	t.hooks = hooks{
+		beforeEach:    append(([]func(testing.TB))(nil), t.hooks.beforeEach...),
+		afterEach:     append(([]func(testing.TB))(nil), t.hooks.afterEach...),
+		beforeSubtree: append(([]func(testing.TB))(nil), t.hooks.beforeSubtree...),
+		afterSubtree:  append(([]func(testing.TB))(nil), t.hooks.afterSubtree...),
+	}
+}
@@ -0,0 +1,123 @@
+package tea
+
+import (
+	"testing"
+)
+
+func TestGraft(t *testing.T) {
+	t.Run("markers added after grafting", func(t *testing.T) {
+		check := func(testing.TB, Env) {}
+		hook := func(testing.TB) {}
+		template := New(&saveX{X: 1}).
+			Label("l1", "l2", "l3").
+			Invariant("i1", check).Invariant("i2", check).Invariant("i3", check).
+			Decorate(Timing, Timing, Timing).
+			BeforeEach(hook).BeforeEach(hook).BeforeEach(hook).
+			AfterEach(hook).AfterEach(hook).AfterEach(hook).
+			BeforeSubtree(hook).BeforeSubtree(hook).BeforeSubtree(hook).
+			AfterSubtree(hook).AfterSubtree(hook).AfterSubtree(hook)
+
+		root := New(&saveX{X: 0})
+		one := root.Graft(template)
+		two := root.Graft(template)
+		for _, g := range []*Tree{one, two} {
+			g.Label("g").Invariant("g", check).Decorate(Timing).
+				BeforeEach(hook).AfterEach(hook).BeforeSubtree(hook).AfterSubtree(hook)
+		}
+
+		for _, node := range []*Tree{template, one, two} {
+			want := 3
+			if node != template {
+				want = 4
+			}
+			counts := []int{
+				len(node.labels), len(node.invariants), len(node.decorators),
+				len(node.hooks.beforeEach), len(node.hooks.afterEach),
+				len(node.hooks.beforeSubtree), len(node.hooks.afterSubtree),
+			}
+			for _, n := range counts {
+				if n != want {
+					t.Errorf("expected every marker of %s to have %d entries, saw %v", node.ID(), want, counts)
+					break
+				}
+			}
+		}
+		if one.invariants[3].desc != "g" || two.invariants[3].desc != "g" || one.labels[3] != "g" {
+			t.Errorf("expected each graft to keep its own markers")
+		}
+	})
+
+	t.Run("grafts are independent", func(t *testing.T) {
+		var log []string
+		template := New(&record{name: "check", log: &log}).Label("checks")
+		template.Child(&record{name: "recheck", log: &log})
+
+		root := New(&record{name: "A", log: &log})
+		one := root.Graft(template)
+		two := root.Child(&record{name: "B", log: &log}).Graft(template)
+
+		if one == template || two == template || one == two {
+			t.Fatalf("expected grafts to be copies of the template")
+		}
+		if one.Parent() != root || template.Parent() != nil {
+			t.Errorf("unexpected parents for graft and template")
+		}
+		if one.ID() != "A/check" || two.Children()[0].ID() != "A/B/check/recheck" {
+			t.Errorf("unexpected graft IDs: %s, %s", one.ID(), two.Children()[0].ID())
+		}
+		if !hasLabel(two.Children()[0].allLabels(), "checks") {
+			t.Errorf("expected grafted nodes to keep their labels")
+		}
+
+		one.Child(&record{name: "extra", log: &log})
+		if len(template.children) != 1 || len(two.children) != 1 {
+			t.Errorf("adding a child to a graft changed the template or another graft")
+		}
+		if one.test == template.test {
+			t.Errorf("expected graft to have a copy of the template's test")
+		}
+
+		new(runner).run(testT{t}, root)
+	})
+
+	t.Run("overrides", func(t *testing.T) {
+		template := New(&checkX{expect: 1})
+		root := New(&saveX{X: 1})
+		root.Graft(template)
+		graft := root.Child(&saveX{X: 2}).Graft(template, func(test Test) {
+			if c, ok := test.(*checkX); ok {
+				c.expect = 2
+			}
+		})
+		if graft.test.(*checkX).expect != 2 || template.test.(*checkX).expect != 1 {
+			t.Errorf("expected override to apply to the graft only")
+		}
+		new(runner).run(testT{t}, root)
+	})
+
+	t.Run("override a field by name", func(t *testing.T) {
+		template := New(&saveX{X: 1})
+		template.Child(&checkX{expect: 1})
+
+		graft := New(&saveX{}).Graft(template, Override("X", 5))
+		if graft.test.(*saveX).X != 5 || graft.children[0].test.(*checkX).X != 5 {
+			t.Errorf("expected field X to be overridden on every grafted test")
+		}
+	})
+
+	t.Run("joins within the template", func(t *testing.T) {
+		var log []string
+		template := New(&saveX{X: 1})
+		user := template.Child(&saveUser{User: "alice", log: &log})
+		product := template.Child(&saveProduct{Product: "tea", log: &log})
+		Join(&purchase{log: &log}, user, product)
+
+		root := New(&saveX{X: 0})
+		graft := root.Graft(template)
+		join := graft.children[1].children[0]
+		parents := join.Parents()
+		if len(parents) != 2 || parents[0] != graft.children[0] || parents[1] != graft.children[1] {
+			t.Errorf("expected grafted join to be joined to grafted parents")
+		}
+	})
+}
@@ -0,0 +1,66 @@
+package tea
+
+import (
+	"reflect"
+)
+
+// When attaches a guard to the edge between a tree node and its parent,
+// returning the node so that calls may be chained onto Child. The node is run
+// only if cond is true of the environment produced by its parents; when cond
+// is false, the node and all of its descendants are reported as skipped for
+// being not applicable, rather than failed or skipped for a failed
+// dependency. desc describes the condition, for reporting, e.g.:
+//
+//	root.Child(&testEnableBeta{}).
+//		Child(&testBetaSearch{}).When("beta enabled", tea.Equal("Beta", true))
+//
+// A node has at most one guard; calling When again replaces it.
+func (t *Tree) When(desc string, cond func(Env) bool) *Tree {
+	t.guard = &guard{desc: desc, cond: cond}
+	return t
+}
+
+// Equal creates a condition for When that is true when the most recent value
+// saved for the named field is equal to value.
+func Equal(field string, value interface{}) func(Env) bool {
+	return func(e Env) bool {
+		v, ok := e.Lookup(field)
+		return ok && reflect.DeepEqual(v, value)
+	}
+}
+
+// Env is a read-only view of the fields saved by the ancestors of a node, as
+// given to the condition of a guard.
+type Env struct {
+	e *env
+}
+
+// Lookup finds the most recent value saved for the named field, reporting
+// whether any value was found.
+func (e Env) Lookup(field string) (interface{}, bool) {
+	for l := e.e; l != nil; l = l.parent {
+		if v, ok := l.data[field]; ok {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+func (e Env) String() string {
+	if e.e == nil {
+		return "{}"
+	}
+	return e.e.String()
+}
+
+// guard is a condition on the environment in which a node is run.
+type guard struct {
+	desc string
+	cond func(Env) bool
+}
+
+// met checks whether a guard is met by an environment. A nil guard is always
+// met.
+func (g *guard) met(e *env) bool {
+	return g == nil || g.cond(Env{e})
+}
@@ -0,0 +1,44 @@
+package tea
+
+import (
+	"testing"
+)
+
+type saveBeta struct {
+	Beta bool `tea:"save"`
+}
+
+func (test *saveBeta) Run(t *testing.T) {}
+
+func TestWhen(t *testing.T) {
+	var log []string
+	root := New(&record{name: "A", log: &log})
+	on := root.Child(&saveBeta{Beta: true})
+	off := root.Child(&saveBeta{Beta: false})
+	on.Child(&record{name: "beta on", log: &log}).When("beta enabled", Equal("Beta", true))
+	guarded := off.Child(&record{name: "beta off", log: &log}).When("beta enabled", Equal("Beta", true))
+	guarded.Child(&record{name: "beta off child", log: &log})
+
+	t.Run("unmet guards are not applicable", func(t *testing.T) {
+		log = nil
+		new(runner).run(testT{t}, root)
+		assertLog(t, log, "A", "A", "A", "beta on", "A", "A")
+	})
+
+	t.Run("reason", func(t *testing.T) {
+		x := new(runner).exec(testT{t}, guarded)
+		if x.inapplicable != "tea not applicable: beta enabled" {
+			t.Errorf("unexpected reason for unmet guard: %q", x.inapplicable)
+		}
+	})
+
+	t.Run("lookup", func(t *testing.T) {
+		e := Env{mkenv(&saveX{X: 1}).save(&saveX{X: 2})}
+		if v, ok := e.Lookup("X"); !ok || v != 2 {
+			t.Errorf("expected to look up the most recent X, saw %v", v)
+		}
+		if _, ok := e.Lookup("Y"); ok {
+			t.Errorf("expected not to find Y in %v", e)
+		}
+	})
+}
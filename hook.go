@@ -0,0 +1,104 @@
+package tea
+
+import (
+	"testing"
+)
+
+// BeforeEach registers a function to be called before each descendant of a
+// tree node is run, returning the node so that calls may be chained onto
+// Child. Since every node is run in a subtest of its own, a BeforeEach hook
+// is called at the start of the subtest of each descendant, before the
+// descendant's ancestors are replayed. BeforeEach lets shared setup, such as
+// resetting a stand-in server between sibling branches, be performed without
+// a dedicated Test type placed in every path:
+//
+//	root := tea.New(&testStartServer{})
+//	root.BeforeEach(func(t testing.TB) { stub.Reset() })
+//
+// The BeforeEach hooks of a node's ancestors are called starting from the
+// root. If a hook fails, the node is not run, and its descendants are skipped
+// as if the node had failed.
+func (t *Tree) BeforeEach(hook func(testing.TB)) *Tree {
+	t.hooks.beforeEach = append(t.hooks.beforeEach, hook)
+	return t
+}
+
+// AfterEach registers a function to be called after each descendant of a
+// tree node is run and the After methods of its tests have been called,
+// before the descendant's own children are run. The AfterEach hooks of a
+// node's ancestors are called starting from the node's parent.
+func (t *Tree) AfterEach(hook func(testing.TB)) *Tree {
+	t.hooks.afterEach = append(t.hooks.afterEach, hook)
+	return t
+}
+
+// BeforeSubtree registers a function to be called once, before a tree node
+// is run, returning the node so that calls may be chained onto Child. It is
+// called within the node's subtest, before the BeforeEach hooks of the node's
+// ancestors.
+func (t *Tree) BeforeSubtree(hook func(testing.TB)) *Tree {
+	t.hooks.beforeSubtree = append(t.hooks.beforeSubtree, hook)
+	return t
+}
+
+// AfterSubtree registers a function to be called once, after a tree node and
+// all of its descendants have been run, returning the node so that calls may
+// be chained onto Child. AfterSubtree hooks are called in the reverse order
+// of their registration, and are called even if the node fails. Hooks are
+// not called for nodes that are skipped without being run.
+func (t *Tree) AfterSubtree(hook func(testing.TB)) *Tree {
+	t.hooks.afterSubtree = append(t.hooks.afterSubtree, hook)
+	return t
+}
+
+// hooks are the functions registered on a tree node to be called around the
+// running of the node and its descendants.
+type hooks struct {
+	beforeEach    []func(testing.TB)
+	afterEach     []func(testing.TB)
+	beforeSubtree []func(testing.TB)
+	afterSubtree  []func(testing.TB)
+}
+
+// before calls the BeforeSubtree hooks of a tree node and then the BeforeEach
+// hooks of its ancestors, reporting whether they all passed.
+func (t *Tree) before(tb testing.TB) bool {
+	for _, hook := range t.hooks.beforeSubtree {
+		call(tb, hook)
+	}
+	nodes := t.ancestry()
+	for _, node := range nodes[:len(nodes)-1] {
+		for _, hook := range node.hooks.beforeEach {
+			call(tb, hook)
+		}
+	}
+	return !tb.Failed()
+}
+
+// after calls the AfterEach hooks of the ancestors of a tree node.
+func (t *Tree) after(tb testing.TB) {
+	nodes := t.ancestry()
+	for i := len(nodes) - 2; i >= 0; i-- {
+		for _, hook := range nodes[i].hooks.afterEach {
+			call(tb, hook)
+		}
+	}
+}
+
+// afterSubtree calls the AfterSubtree hooks of a tree node.
+func (t *Tree) afterSubtree(tb testing.TB) {
+	hooks := t.hooks.afterSubtree
+	for i := len(hooks) - 1; i >= 0; i-- {
+		call(tb, hooks[i])
+	}
+}
+
+// call calls a hook. The hook is run against its own recorder, so that a hook
+// calling Fatal stops only the hook, and not the running of the tree.
+func call(tb testing.TB, hook func(testing.TB)) {
+	rec := capture(tb, hook)
+	rec.replay(tb)
+	if rec.Failed() {
+		tb.Fail()
+	}
+}
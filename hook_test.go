@@ -0,0 +1,53 @@
+package tea
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestHooks(t *testing.T) {
+	var log []string
+	hook := func(name string) func(testing.TB) {
+		return func(testing.TB) { log = append(log, name) }
+	}
+
+	root := New(&record{name: "A", log: &log}).
+		BeforeEach(hook("before each")).
+		AfterEach(hook("after each")).
+		BeforeSubtree(hook("before A")).
+		AfterSubtree(hook("after A"))
+	b := root.Child(&record{name: "B", log: &log}).
+		BeforeSubtree(hook("before B")).
+		AfterSubtree(hook("after B"))
+	b.Child(&record{name: "C", log: &log})
+	root.Child(&record{name: "D", log: &log})
+	new(runner).run(testT{t}, root)
+
+	assertLog(t, log,
+		"before A", "A",
+		"before B", "before each", "A", "B", "after each",
+		"before each", "A", "B", "C", "after each",
+		"after B",
+		"before each", "A", "D", "after each",
+		"after A",
+	)
+}
+
+func TestFailedHook(t *testing.T) {
+	var log []string
+	root := New(&saveX{X: 1})
+	root.Child(&saveX{X: 2}).
+		BeforeSubtree(func(t testing.TB) { t.Fatal("reset failed") }).
+		AfterSubtree(func(testing.TB) { log = append(log, "after subtree") }).
+		Child(&checkX{expect: 2})
+
+	var buf bytes.Buffer
+	if Standalone(&buf, root) {
+		t.Fatalf("expected tree to fail:\n%s", buf.String())
+	}
+	assertLog(t, log, "after subtree")
+	if !strings.Contains(buf.String(), "--- SKIP: saveX/saveX/checkX ") {
+		t.Errorf("expected the children of a node with a failed hook to be skipped:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,48 @@
+package tea
+
+import (
+	"testing"
+)
+
+// Invariant registers a check on a tree node, returning the node so that
+// calls may be chained onto Child. Invariants are inherited: after every node
+// is run, the invariants of the node and of all of its ancestors are checked
+// against the environment produced by the node, before any After methods are
+// called. An invariant lets a property that should hold at every step, e.g.
+// that the total number of hits equals the sum of the hits of each path, be
+// verified on every path through a tree without adding an assertion to every
+// test type:
+//
+//	root.Invariant("total is sum of paths", func(t testing.TB, e tea.Env) {
+//		v, _ := e.Lookup("Server")
+//		...
+//	})
+//
+// A failed invariant fails the node just as a failure of its own test would.
+// Invariants are not checked for nodes that have already failed or that
+// failed as expected.
+func (t *Tree) Invariant(desc string, check func(testing.TB, Env)) *Tree {
+	t.invariants = append(t.invariants, invariant{desc: desc, check: check})
+	return t
+}
+
+// invariant is a named check on the environment produced by a node.
+type invariant struct {
+	desc  string
+	check func(testing.TB, Env)
+}
+
+// checkInvariants checks the invariants of a tree node and its ancestors
+// against the environment e produced by the node. The failures of each
+// invariant are reported under its description.
+func (t *Tree) checkInvariants(tb testing.TB, e *env) {
+	for _, node := range t.ancestry() {
+		for _, inv := range node.invariants {
+			rec := capture(tb, func(tb testing.TB) { inv.check(tb, Env{e}) })
+			rec.replay(tb)
+			if rec.Failed() {
+				tb.Errorf("tea invariant violated: %s", inv.desc)
+			}
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package tea
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestInvariant(t *testing.T) {
+	t.Run("checked after every node", func(t *testing.T) {
+		var seen []interface{}
+		root := New(&saveX{X: 1}).Invariant("record X", func(t testing.TB, e Env) {
+			x, _ := e.Lookup("X")
+			seen = append(seen, x)
+		})
+		child := root.Child(&saveX{X: 2})
+		child.Child(&saveX{X: 3})
+		child.Child(&checkX{expect: 2})
+		new(runner).run(testT{t}, root)
+
+		want := []interface{}{1, 2, 3, 2}
+		if len(seen) != len(want) {
+			t.Fatalf("expected invariant to be checked %d times, saw %v", len(want), seen)
+		}
+		for i := range want {
+			if seen[i] != want[i] {
+				t.Errorf("expected check %d to see X=%v, saw %v", i, want[i], seen[i])
+			}
+		}
+	})
+
+	t.Run("violations fail the node", func(t *testing.T) {
+		root := New(&saveX{X: 1})
+		root.Child(&saveX{X: 2}).Invariant("X is odd", func(t testing.TB, e Env) {
+			if x, _ := e.Lookup("X"); x.(int)%2 == 0 {
+				t.Fatalf("X is %v", x)
+			}
+		}).Child(&saveX{X: 3})
+		root.Child(&saveX{X: 5})
+
+		var buf bytes.Buffer
+		if Standalone(&buf, root) {
+			t.Fatalf("expected tree to fail:\n%s", buf.String())
+		}
+		out := buf.String()
+		for _, line := range []string{
+			"--- FAIL: saveX/saveX ",
+			"X is 2",
+			"tea invariant violated: X is odd",
+			"--- SKIP: saveX/saveX/saveX ",
+			"--- PASS: saveX/saveX#01 ",
+		} {
+			if !strings.Contains(out, line) {
+				t.Errorf("expected output to contain %q:\n%s", line, out)
+			}
+		}
+	})
+}
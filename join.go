@@ -0,0 +1,123 @@
+package tea
+
+import (
+	"fmt"
+)
+
+// Join creates a new Tree node having each of the provided nodes as a parent,
+// for a test that depends on the state produced by more than one independent
+// chain of tests, e.g.:
+//
+//	root := tea.New(&testStartServer{})
+//	user := root.Child(&testCreateUser{Name: "alice"})
+//	product := root.Child(&testCreateProduct{SKU: "tea-001"})
+//	tea.Join(&testPurchase{}, user, product)
+//
+// When a join node is run, each of its ancestors is run exactly once, even
+// if it is an ancestor by more than one path, with every node running after
+// all of its parents: above, testStartServer runs once, followed by
+// testCreateUser, testCreateProduct and finally testPurchase. After methods
+// are called once for each test, in the reverse order. The join node's test
+// is loaded from the merged environments of its parents. If a field is saved
+// by more than one parent's chain, other than by an ancestor that the chains
+// share, the merge is a test plan error, since it is unclear which value the
+// join node should load.
+//
+// The join node is added as a child of just one of its parents, its primary
+// parent, which is the parent that Run visits last. Its path ID and the name
+// of its Go subtest are derived from its primary parent. If any other parent
+// fails before the join node is run, the join node is skipped.
+func Join(test Test, parents ...*Tree) *Tree {
+	if len(parents) == 0 {
+		return New(test)
+	}
+
+	primary := parents[len(parents)-1]
+	order := make(map[*Tree]int)
+	primary.root().Walk(func(node *Tree) bool {
+		order[node] = len(order)
+		return true
+	})
+	for _, parent := range parents {
+		i, ok := order[parent]
+		if ok && i > order[primary] {
+			primary = parent
+		}
+	}
+
+	child := primary.Child(test)
+	if len(parents) > 1 {
+		child.parents = append([]*Tree(nil), parents...)
+	}
+	return child
+}
+
+// Parents returns all of the parents of a tree node. A node created by Join
+// may have more than one parent; every other node has one parent, except for
+// the root, which has none.
+func (t *Tree) Parents() []*Tree {
+	if len(t.parents) > 0 {
+		return append([]*Tree(nil), t.parents...)
+	}
+	if t.parent != nil {
+		return []*Tree{t.parent}
+	}
+	return nil
+}
+
+// root returns the root of the tree containing a node, following primary
+// parents.
+func (t *Tree) root() *Tree {
+	for t.parent != nil {
+		t = t.parent
+	}
+	return t
+}
+
+// ancestry returns a node and all of its ancestors, in the order in which
+// they are run: each node appears exactly once, after all of its parents,
+// and the node itself appears last.
+func (t *Tree) ancestry() []*Tree {
+	var (
+		nodes []*Tree
+		seen  = make(map[*Tree]bool)
+		visit func(*Tree)
+	)
+	visit = func(node *Tree) {
+		if seen[node] {
+			return
+		}
+		seen[node] = true
+		for _, parent := range node.Parents() {
+			visit(parent)
+		}
+		nodes = append(nodes, node)
+	}
+	visit(t)
+	return nodes
+}
+
+// parentEnv merges the environments produced by the parents of a node, as
+// found in envs, yielding the environment in which the node's test is run.
+func parentEnv(tree *Tree, envs map[*Tree]*env) (*env, error) {
+	parents := tree.Parents()
+	e := envs[parents[0]]
+	for _, parent := range parents[1:] {
+		var err error
+		if e, err = e.merge(envs[parent]); err != nil {
+			return nil, fmt.Errorf("unable to join %s: %w", parent.ID(), err)
+		}
+	}
+	return e, nil
+}
+
+// joinFailed checks whether any parent of a join node other than its primary
+// parent has failed or been skipped.
+func (r *runner) joinFailed(tree *Tree) bool {
+	for _, parent := range tree.parents {
+		if passed, ok := r.passed[parent]; ok && !passed {
+			return true
+		}
+	}
+	return false
+}
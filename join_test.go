@@ -0,0 +1,108 @@
+package tea
+
+import (
+	"testing"
+)
+
+type saveUser struct {
+	User string `tea:"save"`
+	log  *[]string
+}
+
+func (test *saveUser) Run(t *testing.T) { *test.log = append(*test.log, "user") }
+
+type saveProduct struct {
+	Product string `tea:"save"`
+	log     *[]string
+}
+
+func (test *saveProduct) Run(t *testing.T) { *test.log = append(*test.log, "product") }
+
+type purchase struct {
+	User    string `tea:"load"`
+	Product string `tea:"load"`
+	log     *[]string
+}
+
+func (test *purchase) Run(t *testing.T) {
+	*test.log = append(*test.log, "purchase "+test.User+" "+test.Product)
+}
+
+func TestJoin(t *testing.T) {
+	t.Run("diamond", func(t *testing.T) {
+		var log []string
+		var teardowns int
+		root := New(&teardownCount{n: &teardowns})
+		user := root.Child(&saveUser{User: "alice", log: &log})
+		product := root.Child(&saveProduct{Product: "tea", log: &log})
+		join := Join(&purchase{log: &log}, user, product)
+
+		if join.Parent() != product {
+			t.Errorf("expected join to be run under its last parent, is under %s", join.Parent().ID())
+		}
+		if parents := join.Parents(); len(parents) != 2 || parents[0] != user || parents[1] != product {
+			t.Errorf("unexpected parents of join: %v", parents)
+		}
+
+		log = nil
+		teardowns = 0
+		x := new(runner).exec(testT{t}, join)
+		teardown(testT{t}, x.history)
+		assertLog(t, log, "user", "product", "purchase alice tea")
+		if teardowns != 1 {
+			t.Errorf("expected shared ancestor to be torn down once, saw %d", teardowns)
+		}
+	})
+
+	t.Run("primary parent is visited last", func(t *testing.T) {
+		var log []string
+		root := New(&saveX{X: 1})
+		product := root.Child(&saveProduct{Product: "tea", log: &log})
+		user := root.Child(&saveUser{User: "alice", log: &log})
+		join := Join(&purchase{log: &log}, user, product)
+		if join.Parent() != user {
+			t.Errorf("expected join to be run under %s, is under %s", user.ID(), join.Parent().ID())
+		}
+
+		log = nil
+		new(runner).run(testT{t}, root)
+		assertLog(t, log, "product", "user", "user", "product", "purchase alice tea")
+	})
+
+	t.Run("conflicting saves", func(t *testing.T) {
+		root := New(&saveX{X: 1})
+		a := root.Child(&saveX{X: 2})
+		b := root.Child(&saveX{X: 3})
+		join := Join(&checkX{}, a, b)
+
+		envs := map[*Tree]*env{root: mkenv(&saveX{X: 1})}
+		envs[a] = envs[root].save(&saveX{X: 2})
+		envs[b] = envs[root].save(&saveX{X: 3})
+		if _, err := parentEnv(join, envs); err == nil {
+			t.Errorf("expected a conflict merging environments that both save X")
+		} else {
+			assertErrorType(t, err, PlanError)
+		}
+	})
+
+	t.Run("shared layers do not conflict", func(t *testing.T) {
+		base := mkenv(&saveX{X: 1})
+		left := base.save(&saveUser{User: "alice"})
+		right := base.save(&saveProduct{Product: "tea"})
+		merged, err := left.merge(right)
+		if err != nil {
+			t.Fatalf("unexpected merge error: %v", err)
+		}
+		again, err := merged.merge(right)
+		if err != nil {
+			t.Fatalf("unexpected error merging a copied layer: %v", err)
+		}
+		var test purchase
+		if err := again.load(&test); err != nil {
+			t.Errorf("unexpected load error: %v", err)
+		}
+		if test.User != "alice" || test.Product != "tea" {
+			t.Errorf("unexpected values loaded from merged env: %v", again)
+		}
+	})
+}
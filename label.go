@@ -0,0 +1,118 @@
+package tea
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+// Label attaches labels to a tree node, returning the node so that calls may
+// be chained onto Child. Labels are inherited: a node carries its own labels
+// as well as the labels of all of its ancestors. A label is an arbitrary
+// string such as "slow" or "integration"; labels of the form key=value, such
+// as "owner=payments", may also be selected by their key alone.
+//
+// Nodes may be selected or excluded by label with the -tea.labels and
+// -tea.exclude-labels flags, or the TEA_LABELS and TEA_EXCLUDE_LABELS
+// environment variables, each of which is a comma-separated list of labels.
+// When running with -short, nodes labelled "slow" are excluded.
+func (t *Tree) Label(labels ...string) *Tree {
+	t.labels = append(t.labels, labels...)
+	return t
+}
+
+// allLabels returns the labels of a tree node and all of its ancestors.
+func (t *Tree) allLabels() []string {
+	var labels []string
+	for _, node := range t.ancestry() {
+		labels = append(labels, node.labels...)
+	}
+	return labels
+}
+
+// excluded checks whether a tree node is excluded from a run by its labels.
+// If the node is excluded, excluded returns a reason to be used when
+// reporting the node as skipped. A node that is not itself selected by the
+// runner's included labels is not excluded if any of its descendants are
+// selected, since it is a prerequisite of those descendants.
+func (r *runner) excluded(tree *Tree) string {
+	labels := tree.allLabels()
+	for _, label := range r.exclude {
+		if hasLabel(labels, label) {
+			return fmt.Sprintf("tea excluded: labelled %q", label)
+		}
+	}
+	if len(r.include) > 0 && !r.wanted(tree) {
+		return fmt.Sprintf("tea excluded: not labelled with any of %s", strings.Join(r.include, ", "))
+	}
+	return ""
+}
+
+// wanted checks whether a tree node or any of its descendants has any of the
+// runner's included labels.
+func (r *runner) wanted(tree *Tree) bool {
+	labels := tree.allLabels()
+	for _, label := range r.include {
+		if hasLabel(labels, label) {
+			return true
+		}
+	}
+	for _, child := range tree.children {
+		if r.wanted(child) {
+			return true
+		}
+	}
+	return false
+}
+
+// setLabels configures the labels selected and excluded by the runner from
+// tea's flags and environment variables.
+func (r *runner) setLabels() {
+	include := *labelsFlag
+	if include == "" {
+		include = os.Getenv("TEA_LABELS")
+	}
+	exclude := *excludeLabelsFlag
+	if exclude == "" {
+		exclude = os.Getenv("TEA_EXCLUDE_LABELS")
+	}
+
+	r.include = splitLabels(include)
+	r.exclude = splitLabels(exclude)
+	if short() && !hasLabel(r.exclude, "slow") {
+		r.exclude = append(r.exclude, "slow")
+	}
+}
+
+// short reports whether the -test.short flag is set. Unlike testing.Short,
+// short may be called outside of go test, where it reports false.
+func short() bool {
+	return flag.Lookup("test.short") != nil && testing.Short()
+}
+
+// hasLabel checks whether a set of labels contains the label selector. A
+// selector matches a label having the same value, or a key=value label having
+// the selector as its key.
+func hasLabel(labels []string, selector string) bool {
+	for _, label := range labels {
+		if label == selector || strings.HasPrefix(label, selector+"=") {
+			return true
+		}
+	}
+	return false
+}
+
+// splitLabels splits a comma-separated list of labels, ignoring empty
+// entries.
+func splitLabels(s string) []string {
+	var labels []string
+	for _, label := range strings.Split(s, ",") {
+		label = strings.TrimSpace(label)
+		if label != "" {
+			labels = append(labels, label)
+		}
+	}
+	return labels
+}
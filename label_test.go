@@ -0,0 +1,116 @@
+package tea
+
+import (
+	"flag"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestLabels(t *testing.T) {
+	var log []string
+	root := New(&record{name: "A", log: &log})
+	b := root.Child(&record{name: "B", log: &log}).Label("slow")
+	b.Child(&record{name: "C", log: &log})
+	d := root.Child(&record{name: "D", log: &log})
+	d.Child(&record{name: "E", log: &log}).Label("owner=payments")
+
+	t.Run("labels are inherited", func(t *testing.T) {
+		labels := b.children[0].allLabels()
+		if !hasLabel(labels, "slow") {
+			t.Errorf("expected inherited label slow, saw labels %v", labels)
+		}
+	})
+
+	t.Run("exclude a label", func(t *testing.T) {
+		log = nil
+		r := &runner{exclude: []string{"slow"}}
+		r.run(testT{t}, root)
+		assertLog(t, log, "A", "A", "D", "A", "D", "E")
+	})
+
+	t.Run("include a label", func(t *testing.T) {
+		log = nil
+		r := &runner{include: []string{"slow"}}
+		r.run(testT{t}, root)
+		assertLog(t, log, "A", "A", "B", "A", "B", "C")
+	})
+
+	t.Run("include a label by its key", func(t *testing.T) {
+		log = nil
+		r := &runner{include: []string{"owner"}}
+		r.run(testT{t}, root)
+		assertLog(t, log, "A", "A", "D", "A", "D", "E")
+	})
+
+	t.Run("exclusion reason", func(t *testing.T) {
+		r := &runner{exclude: []string{"slow"}}
+		if reason := r.excluded(b.children[0]); reason != `tea excluded: labelled "slow"` {
+			t.Errorf("unexpected exclusion reason: %q", reason)
+		}
+	})
+}
+
+func TestSplitLabels(t *testing.T) {
+	labels := splitLabels(" slow, ,owner=payments,")
+	if len(labels) != 2 || labels[0] != "slow" || labels[1] != "owner=payments" {
+		t.Errorf("unexpected labels: %q", labels)
+	}
+}
+
+// setenv sets an environment variable for the duration of a test, returning a
+// function that restores its previous value.
+func setenv(key, value string) func() {
+	prev, set := os.LookupEnv(key)
+	os.Setenv(key, value)
+	return func() {
+		if set {
+			os.Setenv(key, prev)
+		} else {
+			os.Unsetenv(key)
+		}
+	}
+}
+
+func TestSetLabels(t *testing.T) {
+	include, exclude := *labelsFlag, *excludeLabelsFlag
+	*labelsFlag, *excludeLabelsFlag = "", ""
+	defer func() { *labelsFlag, *excludeLabelsFlag = include, exclude }()
+	defer setenv("TEA_LABELS", "")()
+	defer setenv("TEA_EXCLUDE_LABELS", "")()
+
+	shortFlag := flag.Lookup("test.short").Value
+	defer shortFlag.Set(shortFlag.String())
+
+	assertLabels := func(t *testing.T, name string, labels, expected []string) {
+		if !reflect.DeepEqual(labels, expected) {
+			t.Errorf("expected %s labels %v, saw %v instead", name, expected, labels)
+		}
+	}
+
+	t.Run("short excludes slow", func(t *testing.T) {
+		shortFlag.Set("true")
+		defer shortFlag.Set("false")
+		r := new(runner)
+		r.setLabels()
+		assertLabels(t, "excluded", r.exclude, []string{"slow"})
+	})
+
+	t.Run("environment", func(t *testing.T) {
+		defer setenv("TEA_LABELS", "db,owner=payments")()
+		defer setenv("TEA_EXCLUDE_LABELS", "flaky")()
+		r := new(runner)
+		r.setLabels()
+		assertLabels(t, "included", r.include, []string{"db", "owner=payments"})
+		assertLabels(t, "excluded", r.exclude, []string{"flaky"})
+	})
+
+	t.Run("flags override the environment", func(t *testing.T) {
+		defer setenv("TEA_LABELS", "db")()
+		*labelsFlag = "api"
+		defer func() { *labelsFlag = "" }()
+		r := new(runner)
+		r.setLabels()
+		assertLabels(t, "included", r.include, []string{"api"})
+	})
+}
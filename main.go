@@ -0,0 +1,359 @@
+package tea
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"testing"
+)
+
+// registry holds the trees and fixtures registered to be run by
+// RunRegistered, along with the configuration given to Main.
+var registry struct {
+	mu       sync.Mutex
+	trees    []*Tree
+	fixtures []*fixture
+	config   Config
+
+	// ran is true once RunRegistered has been called.
+	ran bool
+}
+
+// Register registers a tree to be run by RunRegistered, returning the tree.
+// Register lets a package declare its trees without writing a Go test function
+// to run each one, e.g.:
+//
+//	var _ = tea.Register(serverTree())
+func Register(tree *Tree) *Tree {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	registry.trees = append(registry.trees, tree)
+	return tree
+}
+
+// RegisterFixture registers a fixture shared by every tree run by
+// RunRegistered. The
+// fixture is loaded exactly as a fixture registered on a tree with the
+// Fixture method, except that it is visible to every node of every registered
+// tree, and is torn down once every node that loads it has finished, or
+// once every registered tree has been run.
+func RegisterFixture(name string, setup func(testing.TB) (interface{}, func())) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	registry.fixtures = append(registry.fixtures, &fixture{name: name, setup: setup})
+}
+
+// Config is the configuration given to Main for every registered tree. The
+// zero value runs the registered trees as Run would run them.
+type Config struct {
+	// Labels and ExcludeLabels select and exclude nodes by label, in
+	// addition to the labels given by -tea.labels and -tea.exclude-labels.
+	Labels        []string
+	ExcludeLabels []string
+
+	// FailFast runs every registered tree in fail-fast mode, with the first
+	// failure in any tree stopping every tree, including the trees running
+	// in parallel with it. A node that is already running when the failure
+	// happens is still run to completion.
+	FailFast bool
+
+	// Parallel runs the registered trees in parallel with one another. The
+	// nodes of each tree are still run one at a time.
+	Parallel bool
+
+	// Report, if not nil, is written an aggregated report of the outcome of
+	// every node of every registered tree once they have all been run,
+	// counting the nodes that passed, failed or were skipped, as well as
+	// those excluded by label, failed as expected, found not applicable or
+	// not run in fail-fast mode, and listing the nodes that failed and the
+	// nodes that passed only after being retried.
+	Report io.Writer
+
+	// Dedupe merges registered trees that begin with identical nodes into a
+	// single tree, in which the nodes they share are run once rather than
+	// once per tree. Nodes are identical if their tests have the same type
+	// and equal field values, and they have the same markers and no hooks,
+	// guards, invariants, decorators or fixtures. Merging does not snapshot
+	// the shared nodes: every node of a merged tree still replays its
+	// ancestors, as it would in any one tree, so only the runs of the shared
+	// nodes themselves are saved. The number of test runs saved is included
+	// in the report.
+	//
+	// Trees containing join nodes or focused nodes are never merged.
+	//
+	// Since merged trees have identical roots, they are run as a single
+	// subtest named after their root, where they would otherwise be run as
+	// subtests named TestTea/root, TestTea/root#01 and so on. Once merged,
+	// -run TestTea/root#01 selects none of them. Merging may also change
+	// path IDs: same-named siblings that come from different trees but are
+	// not identical are numbered together, so if the roots of two merged
+	// trees each have a different child named B, the child having the ID
+	// root/B in the second tree has the ID root/B[1] in the merged tree, and
+	// must be selected by that ID with -tea.path.
+	Dedupe bool
+}
+
+// Main runs the package's Go tests with the provided configuration applied to
+// the registered trees, returning an exit code for the test binary. Main does
+// not run the registered trees itself: they are run by RunRegistered, from a
+// Go test declared by the package, so that they are run just like the
+// package's other Go tests, within the test binary's timeout and coverage
+// profile and subject to -run. Main is intended to be called from TestMain:
+//
+//	func TestMain(m *testing.M) {
+//		os.Exit(tea.Main(m, tea.Config{Report: os.Stdout}))
+//	}
+//
+//	func TestTea(t *testing.T) { tea.RunRegistered(t) }
+//
+// If trees are registered but no Go test calls RunRegistered, Main fails the
+// test binary, unless the Go tests were selected with -run or only listed
+// with -list, since the trees may then have been left out deliberately.
+func Main(m *testing.M, config Config) int {
+	registry.mu.Lock()
+	registry.config = config
+	registry.mu.Unlock()
+
+	code := m.Run()
+
+	registry.mu.Lock()
+	unrun := len(registry.trees)
+	if registry.ran {
+		unrun = 0
+	}
+	registry.mu.Unlock()
+	if code == 0 && unrun > 0 && !testFlagSet("test.run") && !testFlagSet("test.list") {
+		fmt.Fprintf(os.Stderr, "tea: %d registered %s not run: no Go test called tea.RunRegistered\n", unrun, plural(unrun, "tree"))
+		fmt.Println("FAIL")
+		return 1
+	}
+	return code
+}
+
+// testFlagSet checks whether a flag of the testing package was given a value.
+func testFlagSet(name string) bool {
+	f := flag.Lookup(name)
+	return f != nil && f.Value.String() != ""
+}
+
+// RunRegistered runs every registered tree as a subtest of t, configured by
+// the Config given to Main, or by the zero Config if Main is not used. Each
+// tree is run as Run would run it, in a subtest named after the tree's root,
+// so a single tree may be selected with -run TestTea/root, where TestTea is
+// the Go test calling RunRegistered. Since the trees are run by a Go test,
+// go test -list lists only that Go test, not the trees.
+//
+// The registered fixtures are torn down, the aggregated report is written and
+// the profile given by -tea.profile is written once every tree has finished.
+// RunRegistered is meant to be called by a single Go test in a package.
+func RunRegistered(t *testing.T) {
+	if !flag.Parsed() {
+		flag.Parse()
+	}
+
+	registry.mu.Lock()
+	registry.ran = true
+	trees := append([]*Tree(nil), registry.trees...)
+	global := registry.fixtures
+	config := registry.config
+	registry.mu.Unlock()
+
+	var saved savings
+	if config.Dedupe {
+		trees, saved = dedupe(trees)
+	}
+	s := newSuite(config, trees, global)
+	s.report.saved = saved
+
+	// cleanups are called after every parallel subtest has finished.
+	t.Cleanup(func() {
+		s.fixtures.closeAll()
+		if config.Report != nil {
+			s.report.write(config.Report)
+		}
+		if err := activeProfile().flush(); err != nil {
+			t.Error(err)
+		}
+	})
+	for _, tree := range trees {
+		if config.Parallel {
+			s.run(parallelT{testT{t}}, tree)
+		} else {
+			s.run(testT{t}, tree)
+		}
+	}
+}
+
+// suite is the state shared by the runners of every tree run by RunRegistered.
+type suite struct {
+	config   Config
+	fixtures *fixtures
+	report   *report
+
+	// failure is shared by every runner in fail-fast mode.
+	failure *firstFail
+}
+
+func newSuite(config Config, trees []*Tree, global []*fixture) *suite {
+	// every runner of the suite selects nodes by the same path.
+	paths := new(runner)
+	paths.setPath(*pathFlag)
+	return &suite{
+		config:   config,
+		fixtures: &fixtures{global: global, trees: trees, visits: paths.selected},
+		report:   &report{trees: len(trees)},
+		failure:  new(firstFail),
+	}
+}
+
+// run runs a single tree as a member of the suite.
+func (s *suite) run(t tester, tree *Tree) {
+	r := newRunner()
+	r.include = append(r.include, s.config.Labels...)
+	r.exclude = append(r.exclude, s.config.ExcludeLabels...)
+	r.setFocus(t, tree)
+	r.setFailFast(tree)
+	r.fixtures = s.fixtures
+	r.report = s.report
+
+	if s.config.FailFast || *failFastFlag {
+		r.failFast = true
+		r.failure = s.failure
+	}
+
+	r.run(t, tree)
+
+	// a parallel tree is only run once the Go test running it has returned.
+	if _, ok := t.(parallelT); ok {
+		t.Cleanup(func() { r.checkPath(t, tree) })
+		return
+	}
+	r.checkPath(t, tree)
+}
+
+// report is an aggregated report of the outcome of every node run by one or
+// more runners.
+type report struct {
+	trees int
+	saved savings
+
+	mu           sync.Mutex
+	passed       int
+	failed       int
+	skipped      int
+	excluded     int
+	xfailed      int
+	inapplicable int
+	notRun       int
+	failures     []string
+	retried      map[string]int
+}
+
+// status is the outcome of a tree node, as counted by a report.
+type status int
+
+const (
+	statusPassed status = iota
+	statusFailed
+	statusSkipped
+
+	// statusExcluded is the status of a node excluded by its labels.
+	statusExcluded
+
+	// statusXFailed is the status of a node marked with XFail that failed
+	// as expected.
+	statusXFailed
+
+	// statusInapplicable is the status of a node whose guard was not met,
+	// and of its descendants.
+	statusInapplicable
+
+	// statusNotRun is the status of a node left unrun in fail-fast mode.
+	statusNotRun
+)
+
+// add adds the outcome of a tree node to a report, along with the number of
+// attempts made at the node if it has a retry policy. A nil report ignores
+// the outcome.
+func (rep *report) add(tree *Tree, st status, attempts int) {
+	if rep == nil {
+		return
+	}
+	rep.mu.Lock()
+	defer rep.mu.Unlock()
+	switch st {
+	case statusFailed:
+		rep.failed++
+		rep.failures = append(rep.failures, tree.ID())
+	case statusSkipped:
+		rep.skipped++
+	case statusExcluded:
+		rep.excluded++
+	case statusXFailed:
+		rep.xfailed++
+	case statusInapplicable:
+		rep.inapplicable++
+	case statusNotRun:
+		rep.notRun++
+	default:
+		rep.passed++
+		if attempts > 1 {
+			if rep.retried == nil {
+				rep.retried = make(map[string]int)
+			}
+			rep.retried[tree.ID()] = attempts
+		}
+	}
+}
+
+// write writes a report to w.
+func (rep *report) write(w io.Writer) {
+	rep.mu.Lock()
+	defer rep.mu.Unlock()
+
+	total := rep.passed + rep.failed + rep.skipped + rep.excluded + rep.xfailed + rep.inapplicable + rep.notRun
+	fmt.Fprintf(w, "tea: %d %s, %d %s: %d passed, %d failed, %d skipped",
+		rep.trees, plural(rep.trees, "tree"), total, plural(total, "node"),
+		rep.passed, rep.failed, rep.skipped)
+	for _, count := range []struct {
+		n    int
+		desc string
+	}{
+		{rep.excluded, "excluded"},
+		{rep.xfailed, "failed as expected"},
+		{rep.inapplicable, "not applicable"},
+		{rep.notRun, "not run"},
+	} {
+		if count.n > 0 {
+			fmt.Fprintf(w, ", %d %s", count.n, count.desc)
+		}
+	}
+	fmt.Fprintln(w)
+
+	rep.saved.write(w)
+
+	retried := make([]string, 0, len(rep.retried))
+	for id := range rep.retried {
+		retried = append(retried, id)
+	}
+	sort.Strings(retried)
+	for _, id := range retried {
+		fmt.Fprintf(w, "tea: %s passed after %d attempts\n", id, rep.retried[id])
+	}
+
+	failures := append([]string(nil), rep.failures...)
+	sort.Strings(failures)
+	for _, id := range failures {
+		fmt.Fprintf(w, "tea: FAIL %s\n", id)
+	}
+}
+
+func plural(n int, s string) string {
+	if n == 1 {
+		return s
+	}
+	return s + "s"
+}
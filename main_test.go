@@ -0,0 +1,236 @@
+package tea
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSuite(t *testing.T) {
+	var log []string
+	first := New(&record{name: "first", log: &log})
+	first.Child(&record{name: "A", log: &log}).Label("slow")
+	first.Child(&record{name: "B", log: &log})
+	second := New(&record{name: "second", log: &log})
+	second.Child(&record{name: "C", log: &log})
+
+	t.Run("config", func(t *testing.T) {
+		log = nil
+		s := newSuite(Config{ExcludeLabels: []string{"slow"}}, []*Tree{first, second}, nil)
+		s.run(testT{t}, first)
+		s.run(testT{t}, second)
+		assertLog(t, log, "first", "first", "B", "second", "second", "C")
+
+		var buf bytes.Buffer
+		s.report.write(&buf)
+		if out := buf.String(); out != "tea: 2 trees, 5 nodes: 4 passed, 0 failed, 0 skipped, 1 excluded\n" {
+			t.Errorf("unexpected report: %q", out)
+		}
+	})
+
+	t.Run("shared fixtures", func(t *testing.T) {
+		var (
+			setups  int
+			counter int
+		)
+		trees := []*Tree{New(&saveX{X: 1}), New(&saveX{X: 2})}
+		for _, tree := range trees {
+			tree.Child(&useCounter{name: "use", log: &log})
+		}
+		s := newSuite(Config{}, trees, []*fixture{{
+			name: "Counter",
+			setup: func(testing.TB) (interface{}, func()) {
+				setups++
+				return &counter, nil
+			},
+		}})
+		for _, tree := range trees {
+			s.run(testT{t}, tree)
+		}
+		if setups != 1 || counter != 2 {
+			t.Errorf("expected one fixture shared by both trees, saw %d setups and %d uses", setups, counter)
+		}
+		if len(s.fixtures.live) != 0 {
+			t.Errorf("expected fixture to be torn down after its last dependent")
+		}
+	})
+}
+
+func TestReportStatuses(t *testing.T) {
+	var log []string
+	root := New(&saveX{X: 1})
+	root.Child(&checkX{expect: 2}).XFail("X is never 2").Child(&record{name: "after", log: &log})
+	root.Child(&record{name: "guarded", log: &log}).When("X is 2", Equal("X", 2)).
+		Child(&record{name: "guarded child", log: &log})
+	root.Child(&record{name: "pending", log: &log}).Pending()
+
+	s := newSuite(Config{}, []*Tree{root}, nil)
+	s.run(testT{t}, root)
+
+	var buf bytes.Buffer
+	s.report.write(&buf)
+	want := "tea: 1 tree, 6 nodes: 1 passed, 0 failed, 2 skipped, 1 failed as expected, 2 not applicable\n"
+	if out := buf.String(); out != want {
+		t.Errorf("unexpected report: %q", out)
+	}
+}
+
+func TestSuiteFailFast(t *testing.T) {
+	failing := New(&saveX{X: 1})
+	failing.Child(&checkX{expect: 2})
+	passing := New(&saveX{X: 1})
+	passing.Child(&checkX{expect: 1})
+
+	s := newSuite(Config{FailFast: true}, []*Tree{failing, passing}, nil)
+	var buf bytes.Buffer
+	root := &reporter{w: &buf}
+	s.run(root, failing)
+	s.run(root, passing)
+
+	if s.failure.id != "saveX/checkX" {
+		t.Errorf("expected the first failure to be recorded, saw %q", s.failure.id)
+	}
+	var report bytes.Buffer
+	s.report.write(&report)
+	want := "tea: 2 trees, 4 nodes: 1 passed, 1 failed, 0 skipped, 2 not run\ntea: FAIL saveX/checkX\n"
+	if out := report.String(); out != want {
+		t.Errorf("unexpected report:\n%s\noutput:\n%s", out, buf.String())
+	}
+}
+
+func TestRunRegistered(t *testing.T) {
+	registry.mu.Lock()
+	trees, fixtures, config := registry.trees, registry.fixtures, registry.config
+	registry.trees, registry.fixtures = nil, nil
+	registry.mu.Unlock()
+	defer func() {
+		registry.mu.Lock()
+		registry.trees, registry.fixtures, registry.config = trees, fixtures, config
+		registry.mu.Unlock()
+	}()
+
+	var firstLog, secondLog []string
+	Register(New(&record{name: "first", log: &firstLog})).Child(&record{name: "A", log: &firstLog})
+	Register(New(&record{name: "second", log: &secondLog})).Child(&record{name: "B", log: &secondLog})
+
+	for _, parallel := range []bool{false, true} {
+		firstLog, secondLog = nil, nil
+		var report bytes.Buffer
+		registry.config = Config{Parallel: parallel, Report: &report}
+		t.Run("TestTea", RunRegistered)
+		assertLog(t, firstLog, "first", "first", "A")
+		assertLog(t, secondLog, "second", "second", "B")
+		if out := report.String(); out != "tea: 2 trees, 4 nodes: 4 passed, 0 failed, 0 skipped\n" {
+			t.Errorf("unexpected report with Parallel=%t: %q", parallel, out)
+		}
+	}
+}
+
+// TestMainRunsRegistered runs the tests of packages calling Main in test
+// binaries of their own, to check that the registered trees are run as an
+// ordinary Go test, before the binary reports its result and coverage, and
+// that a binary never running them fails.
+func TestMainRunsRegistered(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds test binaries")
+	}
+	gobin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command not found")
+	}
+
+	t.Run("registry", func(t *testing.T) {
+		cmd := exec.Command(gobin, "test", "-v", "-count=1", "-coverpkg=github.com/jordanorelli/tea", "./testdata/registry")
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("go test failed: %v\n%s", err, out)
+		}
+
+		output := string(out)
+		for _, line := range []string{
+			"--- PASS: TestTea/saveX/checkX",
+			"tea: 1 tree, 2 nodes: 2 passed, 0 failed, 0 skipped",
+			"\nPASS\n",
+			"coverage: ",
+		} {
+			if !strings.Contains(output, line) {
+				t.Fatalf("expected output to contain %q:\n%s", line, output)
+			}
+		}
+		if strings.Index(output, "tea: 1 tree") > strings.Index(output, "\nPASS\n") {
+			t.Errorf("expected the report to be written before PASS:\n%s", output)
+		}
+		if strings.Contains(output, "coverage: 0.0%") {
+			t.Errorf("expected the registered trees to be covered:\n%s", output)
+		}
+	})
+
+	t.Run("unrun", func(t *testing.T) {
+		out, err := exec.Command(gobin, "test", "-count=1", "./testdata/unrun").CombinedOutput()
+		if err == nil {
+			t.Fatalf("expected go test to fail when no Go test runs the registered trees:\n%s", out)
+		}
+		if want := "tea: 1 registered tree not run: no Go test called tea.RunRegistered"; !strings.Contains(string(out), want) {
+			t.Errorf("expected output to contain %q:\n%s", want, out)
+		}
+
+		out, err = exec.Command(gobin, "test", "-count=1", "-run", "TestNothing", "./testdata/unrun").CombinedOutput()
+		if err != nil {
+			t.Errorf("expected go test -run to leave out the registered trees: %v\n%s", err, out)
+		}
+	})
+}
+
+// awaitFailure waits for a node of another tree in a suite to fail, closing
+// started once it has started waiting.
+type awaitFailure struct {
+	suite   *suite
+	started chan struct{}
+}
+
+func (test *awaitFailure) Run(t *testing.T) { test.RunTB(t) }
+
+func (test *awaitFailure) RunTB(t testing.TB) {
+	close(test.started)
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(time.Millisecond) {
+		test.suite.failure.mu.Lock()
+		id := test.suite.failure.id
+		test.suite.failure.mu.Unlock()
+		if id != "" {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("no other tree failed")
+		}
+	}
+}
+
+func TestSuiteFailFastConcurrent(t *testing.T) {
+	failing := New(&saveX{X: 1})
+	failing.Child(&checkX{expect: 2})
+	s := newSuite(Config{FailFast: true, Parallel: true}, nil, nil)
+	s.report.trees = 2
+	await := &awaitFailure{suite: s, started: make(chan struct{})}
+	waiting := New(await)
+	waiting.Child(&checkX{expect: 0})
+
+	// the waiting tree starts before the failing tree fails.
+	var failingOut, waitingOut bytes.Buffer
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.run(&reporter{w: &waitingOut}, waiting)
+	}()
+	<-await.started
+	s.run(&reporter{w: &failingOut}, failing)
+	<-done
+
+	var report bytes.Buffer
+	s.report.write(&report)
+	want := "tea: 2 trees, 4 nodes: 2 passed, 1 failed, 0 skipped, 1 not run\ntea: FAIL saveX/checkX\n"
+	if out := report.String(); out != want {
+		t.Errorf("unexpected report:\n%s\noutput:\n%s%s", out, failingOut.String(), waitingOut.String())
+	}
+}
@@ -0,0 +1,263 @@
+package tea
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Matrix describes a set of candidate values for the fields of a test. Each
+// key is the name of an exported field, and each value is the list of
+// candidate values for that field.
+type Matrix map[string][]interface{}
+
+// Nodes is a set of sibling tree nodes, such as those created by expanding a
+// Matrix. Nodes lets us add the same descendants to each node in the set.
+type Nodes []*Tree
+
+// Child creates a new Tree node as a child of each of the nodes in the set,
+// returning the set of newly created child nodes.
+func (n Nodes) Child(test Test) Nodes {
+	children := make(Nodes, len(n))
+	for i, node := range n {
+		children[i] = node.Child(test)
+	}
+	return children
+}
+
+// Graft grafts a copy of a subtree under each of the nodes in the set,
+// returning the set of copies of the subtree's root.
+func (n Nodes) Graft(template *Tree, overrides ...func(Test)) Nodes {
+	grafts := make(Nodes, len(n))
+	for i, node := range n {
+		grafts[i] = node.Graft(template, overrides...)
+	}
+	return grafts
+}
+
+// Matrix expands a test into a set of sibling nodes, added as children of the
+// current tree node: one for every combination of the candidate values in m.
+// Each sibling has a clone of test, with its fields set to one combination of
+// values, and a name that lists those values:
+//
+//	root.Matrix(&testRequest{}, tea.Matrix{
+//		"ContentType": {"json", "xml"},
+//		"Auth":        {"basic", "token"},
+//	}).Child(&testResponse{})
+//
+// creates four nodes, named testRequest(Auth=basic,ContentType=json) and so
+// on, each with its own testResponse child. Fields are set just as by
+// Override, except that Matrix panics on a field that the test does not have,
+// as well as on a field that is unexported or a value that is not assignable
+// to its field. A grafted copy of an expanded node is named in the same way,
+// by the values of its own copy of the test.
+func (t *Tree) Matrix(test Test, m Matrix) Nodes {
+	return t.expand(test, m, product(m))
+}
+
+// Pairwise is like Matrix, except that rather than every combination of
+// values, it creates a smaller set of combinations in which every pair of
+// values for every two fields appears at least once. Pairwise is useful for
+// matrices whose full product would be too large to run.
+func (t *Tree) Pairwise(test Test, m Matrix) Nodes {
+	return t.expand(test, m, pairwise(m))
+}
+
+// expand adds a child for each of the provided combinations of values.
+func (t *Tree) expand(test Test, m Matrix, combos []combo) Nodes {
+	for _, name := range m.fieldNames() {
+		if !hasField(test, name) {
+			panic(fmt.Sprintf("tea: %s has no exported field %s", parseName(test), name))
+		}
+	}
+	params := m.fieldNames()
+	nodes := make(Nodes, 0, len(combos))
+	for _, c := range combos {
+		variant := clone(test)
+		for _, f := range c {
+			Override(f.name, f.value)(variant)
+		}
+		node := t.Child(variant)
+		node.params = params
+		node.name = paramName(variant, params)
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// paramName names a test expanded from a Matrix, listing the values of the
+// fields having the provided names after the test's own name. A test having
+// no such fields is named by parseName.
+func paramName(test Test, params []string) string {
+	name := parseName(test)
+	if len(params) == 0 {
+		return name
+	}
+	V := reflect.ValueOf(subject(test)).Elem()
+	values := make([]string, len(params))
+	for i, param := range params {
+		values[i] = fmt.Sprintf("%s=%v", param, V.FieldByName(param).Interface())
+	}
+	return fmt.Sprintf("%s(%s)", name, strings.Join(values, ","))
+}
+
+// hasField reports whether the test wrapped by a Test value is a pointer to a
+// struct having an exported field with the given name.
+func hasField(test Test, name string) bool {
+	V := reflect.ValueOf(subject(test))
+	if V.Kind() != reflect.Ptr || V.Elem().Kind() != reflect.Struct {
+		return false
+	}
+	f, ok := V.Elem().Type().FieldByName(name)
+	return ok && f.PkgPath == ""
+}
+
+// combo is a combination of values for the fields of a Matrix, ordered by
+// field name.
+type combo []fieldValue
+
+type fieldValue struct {
+	name  string
+	value interface{}
+}
+
+// fieldNames returns the field names of a Matrix in sorted order.
+func (m Matrix) fieldNames() []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// product returns every combination of the values of a Matrix.
+func product(m Matrix) []combo {
+	combos := []combo{nil}
+	for _, name := range m.fieldNames() {
+		var next []combo
+		for _, c := range combos {
+			for _, v := range m[name] {
+				n := make(combo, len(c), len(c)+1)
+				copy(n, c)
+				next = append(next, append(n, fieldValue{name: name, value: v}))
+			}
+		}
+		combos = next
+	}
+	return combos
+}
+
+// pairwise returns a set of combinations of the values of a Matrix covering
+// every pair of values for every two fields. The set is built one field at a
+// time, in the manner of IPOG, without building the full product: each
+// field's values are first added to the combinations built so far, choosing
+// for each combination the value covering the most pairs not yet covered, and
+// the pairs still uncovered are then covered by filling in unset values of
+// combinations added for that purpose, or by adding new ones.
+func pairwise(m Matrix) []combo {
+	if len(m) < 3 {
+		return product(m)
+	}
+	names := m.fieldNames()
+	sizes := make([]int, len(names))
+	for i, name := range names {
+		sizes[i] = len(m[name])
+		if sizes[i] == 0 {
+			return nil
+		}
+	}
+
+	// rows hold the index of the value of each field, or -1 where any value
+	// will do.
+	var rows [][]int
+	for a := 0; a < sizes[0]; a++ {
+		for b := 0; b < sizes[1]; b++ {
+			row := make([]int, len(names))
+			for i := range row {
+				row[i] = -1
+			}
+			row[0], row[1] = a, b
+			rows = append(rows, row)
+		}
+	}
+
+	for k := 2; k < len(names); k++ {
+		// covered[j][vj*sizes[k]+vk] is true once value vj of field j has
+		// appeared alongside value vk of field k.
+		covered := make([][]bool, k)
+		for j := range covered {
+			covered[j] = make([]bool, sizes[j]*sizes[k])
+		}
+		cover := func(row []int) {
+			for j := 0; j < k; j++ {
+				if row[j] >= 0 {
+					covered[j][row[j]*sizes[k]+row[k]] = true
+				}
+			}
+		}
+
+		// horizontal growth: extend each row with the value of field k
+		// covering the most uncovered pairs.
+		for _, row := range rows {
+			best, bestScore := 0, -1
+			for v := 0; v < sizes[k]; v++ {
+				score := 0
+				for j := 0; j < k; j++ {
+					if row[j] >= 0 && !covered[j][row[j]*sizes[k]+v] {
+						score++
+					}
+				}
+				if score > bestScore {
+					best, bestScore = v, score
+				}
+			}
+			row[k] = best
+			cover(row)
+		}
+
+		// vertical growth: cover the remaining pairs with rows having an
+		// unset value for field j, adding rows where there are none.
+		for j := 0; j < k; j++ {
+			for vj := 0; vj < sizes[j]; vj++ {
+				for vk := 0; vk < sizes[k]; vk++ {
+					if covered[j][vj*sizes[k]+vk] {
+						continue
+					}
+					var target []int
+					for _, row := range rows {
+						if row[k] == vk && row[j] < 0 {
+							target = row
+							break
+						}
+					}
+					if target == nil {
+						target = make([]int, len(names))
+						for i := range target {
+							target[i] = -1
+						}
+						target[k] = vk
+						rows = append(rows, target)
+					}
+					target[j] = vj
+					covered[j][vj*sizes[k]+vk] = true
+				}
+			}
+		}
+	}
+
+	combos := make([]combo, len(rows))
+	for r, row := range rows {
+		c := make(combo, len(names))
+		for i, name := range names {
+			v := row[i]
+			if v < 0 {
+				v = 0
+			}
+			c[i] = fieldValue{name: name, value: m[name][v]}
+		}
+		combos[r] = c
+	}
+	return combos
+}
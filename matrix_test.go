@@ -0,0 +1,122 @@
+package tea
+
+import (
+	"fmt"
+	"testing"
+)
+
+type request struct {
+	ContentType string
+	Auth        string
+	Version     int
+	secret      string
+}
+
+func (test *request) Run(t *testing.T) {}
+
+func TestMatrix(t *testing.T) {
+	t.Run("product", func(t *testing.T) {
+		root := New(&saveX{X: 1})
+		nodes := root.Matrix(&request{}, Matrix{
+			"ContentType": {"json", "xml"},
+			"Auth":        {"basic", "token"},
+		})
+
+		var names []string
+		for _, node := range nodes {
+			names = append(names, node.Name())
+		}
+		assertLog(t, names,
+			"request(Auth=basic,ContentType=json)",
+			"request(Auth=basic,ContentType=xml)",
+			"request(Auth=token,ContentType=json)",
+			"request(Auth=token,ContentType=xml)",
+		)
+
+		last := nodes[3].test.(*request)
+		if last.Auth != "token" || last.ContentType != "xml" {
+			t.Errorf("unexpected fields on expanded test: %+v", last)
+		}
+
+		children := nodes.Child(&checkX{expect: 1})
+		if len(children) != 4 || children[2].Parent() != nodes[2] {
+			t.Errorf("expected each expanded node to get its own child")
+		}
+		new(runner).run(testT{t}, root)
+	})
+
+	t.Run("pairwise", func(t *testing.T) {
+		m := Matrix{
+			"ContentType": {"json", "xml", "form"},
+			"Auth":        {"basic", "token", "none"},
+			"Version":     {1, 2, 3},
+		}
+		combos := pairwise(m)
+		if len(combos) >= len(product(m)) {
+			t.Errorf("expected pairwise to choose fewer than %d combinations, chose %d", len(product(m)), len(combos))
+		}
+
+		if n := pairsCovered(combos); n != 27 {
+			t.Errorf("expected pairwise combinations to cover 27 pairs, covered %d", n)
+		}
+	})
+
+	t.Run("pairwise-large", func(t *testing.T) {
+		m := make(Matrix)
+		for f := 0; f < 8; f++ {
+			m[fmt.Sprintf("F%d", f)] = []interface{}{0, 1, 2, 3, 4}
+		}
+		combos := pairwise(m)
+		if len(combos) > 45 {
+			t.Errorf("expected pairwise to choose at most 45 combinations, chose %d", len(combos))
+		}
+		if n := pairsCovered(combos); n != 28*25 {
+			t.Errorf("expected pairwise combinations to cover %d pairs, covered %d", 28*25, n)
+		}
+	})
+
+	t.Run("graft", func(t *testing.T) {
+		template := New(&saveX{X: 1})
+		template.Matrix(&request{}, Matrix{"Auth": {"basic", "token"}})
+		root := New(&saveX{X: 2})
+		graft := root.Graft(template)
+
+		var ids []string
+		for _, child := range graft.Children() {
+			ids = append(ids, child.ID())
+		}
+		assertLog(t, ids, "saveX/saveX/request(Auth=basic)", "saveX/saveX/request(Auth=token)")
+	})
+
+	t.Run("unknown-field", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("expected Matrix to panic on a field the test does not have")
+			}
+		}()
+		New(Pass).Matrix(&request{}, Matrix{"Accept": {"json"}})
+	})
+
+	t.Run("unexported-field", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("expected Matrix to panic on an unexported field")
+			}
+		}()
+		New(Pass).Matrix(&request{}, Matrix{"secret": {"json"}})
+	})
+}
+
+// pairsCovered counts the distinct pairs of field values appearing together
+// in a set of combinations.
+func pairsCovered(combos []combo) int {
+	covered := make(map[[2]string]bool)
+	for _, c := range combos {
+		for i := range c {
+			for j := i + 1; j < len(c); j++ {
+				covered[[2]string{c[i].name + "=" + fmt.Sprint(c[i].value), c[j].name + "=" + fmt.Sprint(c[j].value)}] = true
+			}
+		}
+	}
+	return len(covered)
+}
@@ -0,0 +1,201 @@
+package tea
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// profiler accumulates the time spent running every node of every tree run
+// by the test binary when profiling is enabled by the -tea.profile or
+// -tea.trace flags. Profiles are accumulated across calls to Run, and their
+// files are rewritten after each call, so that the files written last cover
+// every call.
+var profiler = emptyProfile()
+
+// span is the time taken by a single run of a node's test.
+type span struct {
+	node  *Tree
+	start time.Time
+	dur   time.Duration
+}
+
+// profile is a profile of the time spent running tree nodes, both as the
+// target of an execution and as ancestors replayed for the executions of
+// their descendants.
+type profile struct {
+	mu     sync.Mutex
+	start  time.Time
+	nodes  map[*Tree]*nodeProfile
+	events []traceEvent
+	tids   map[*Tree]int
+}
+
+// nodeProfile is the time spent running a single tree node.
+type nodeProfile struct {
+	node    *Tree
+	own     time.Duration
+	runs    int
+	replay  time.Duration
+	replays int
+}
+
+// traceEvent is a complete event in Chrome's trace event format, as read by
+// chrome://tracing and Perfetto.
+type traceEvent struct {
+	Name string                 `json:"name"`
+	Cat  string                 `json:"cat"`
+	Ph   string                 `json:"ph"`
+	Ts   int64                  `json:"ts"`
+	Dur  int64                  `json:"dur"`
+	Pid  int                    `json:"pid"`
+	Tid  int                    `json:"tid"`
+	Args map[string]interface{} `json:"args,omitempty"`
+}
+
+// emptyProfile creates a profile having no nodes.
+func emptyProfile() *profile {
+	return &profile{nodes: make(map[*Tree]*nodeProfile), tids: make(map[*Tree]int)}
+}
+
+// activeProfile returns the profile to be used by a runner, which is nil if
+// profiling is not enabled.
+func activeProfile() *profile {
+	if *profileFlag == "" && *traceFlag == "" {
+		return nil
+	}
+	return profiler
+}
+
+// add adds the spans of an execution having the provided target to a
+// profile. The last span is the run of the target itself, and the spans
+// before it are the replays of its ancestors. A nil profile ignores the
+// execution.
+func (p *profile) add(target *Tree, start time.Time, spans []span) {
+	if p == nil || len(spans) == 0 {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.start.IsZero() {
+		p.start = start
+	}
+	tid, ok := p.tids[target.root()]
+	if !ok {
+		tid = len(p.tids) + 1
+		p.tids[target.root()] = tid
+	}
+
+	last := spans[len(spans)-1]
+	p.events = append(p.events, traceEvent{
+		Name: target.ID(),
+		Cat:  "exec",
+		Ph:   "X",
+		Ts:   start.Sub(p.start).Microseconds(),
+		Dur:  last.start.Add(last.dur).Sub(start).Microseconds(),
+		Pid:  1,
+		Tid:  tid,
+	})
+	for _, s := range spans {
+		n, ok := p.nodes[s.node]
+		if !ok {
+			n = &nodeProfile{node: s.node}
+			p.nodes[s.node] = n
+		}
+		cat := "run"
+		if s.node == target {
+			n.own += s.dur
+			n.runs++
+		} else {
+			cat = "replay"
+			n.replay += s.dur
+			n.replays++
+		}
+		p.events = append(p.events, traceEvent{
+			Name: s.node.name,
+			Cat:  cat,
+			Ph:   "X",
+			Ts:   s.start.Sub(p.start).Microseconds(),
+			Dur:  s.dur.Microseconds(),
+			Pid:  1,
+			Tid:  tid,
+			Args: map[string]interface{}{"id": s.node.ID()},
+		})
+	}
+}
+
+// flush writes the files requested by the -tea.profile and -tea.trace flags.
+// A nil profile writes nothing.
+func (p *profile) flush() error {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if *profileFlag != "" {
+		if err := writeFile(*profileFlag, p.write); err != nil {
+			return fmt.Errorf("unable to write tea profile: %w", err)
+		}
+	}
+	if *traceFlag != "" {
+		if err := writeFile(*traceFlag, p.trace); err != nil {
+			return fmt.Errorf("unable to write tea trace: %w", err)
+		}
+	}
+	return nil
+}
+
+// write writes a profile as a table, with the nodes that are most costly to
+// replay first, since those are the nodes most worth snapshotting.
+func (p *profile) write(w io.Writer) error {
+	nodes := make([]*nodeProfile, 0, len(p.nodes))
+	var own, replay time.Duration
+	for _, n := range p.nodes {
+		nodes = append(nodes, n)
+		own += n.own
+		replay += n.replay
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		a, b := nodes[i], nodes[j]
+		if a.replay != b.replay {
+			return a.replay > b.replay
+		}
+		if a.own != b.own {
+			return a.own > b.own
+		}
+		return a.node.ID() < b.node.ID()
+	})
+
+	fmt.Fprintf(w, "tea profile: %d nodes, %v running nodes, %v replaying ancestors\n", len(nodes), own, replay)
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "own\truns\treplay\treplays\ttotal\t\tnode")
+	for _, n := range nodes {
+		fmt.Fprintf(tw, "%v\t%d\t%v\t%d\t%v\t\t%s\n", n.own, n.runs, n.replay, n.replays, n.own+n.replay, n.node.ID())
+	}
+	return tw.Flush()
+}
+
+// trace writes a profile as a timeline in Chrome's trace event format.
+func (p *profile) trace(w io.Writer) error {
+	return json.NewEncoder(w).Encode(struct {
+		TraceEvents []traceEvent `json:"traceEvents"`
+	}{p.events})
+}
+
+// writeFile writes a file by calling write, replacing any existing file.
+func writeFile(path string, write func(io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,74 @@
+package tea
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestProfile(t *testing.T) {
+	var log []string
+	root := New(&record{name: "A", log: &log})
+	b := root.Child(&record{name: "B", log: &log})
+	b.Child(&record{name: "C", log: &log})
+	root.Child(&record{name: "D", log: &log})
+
+	p := emptyProfile()
+	r := new(runner)
+	r.profile = p
+	r.run(testT{t}, root)
+
+	t.Run("counts", func(t *testing.T) {
+		counts := map[string][2]int{
+			"A":     {1, 3},
+			"A/B":   {1, 1},
+			"A/B/C": {1, 0},
+			"A/D":   {1, 0},
+		}
+		for _, n := range p.nodes {
+			want := counts[n.node.ID()]
+			if n.runs != want[0] || n.replays != want[1] {
+				t.Errorf("expected %s to have %d runs and %d replays, saw %d and %d", n.node.ID(), want[0], want[1], n.runs, n.replays)
+			}
+		}
+		if len(p.nodes) != len(counts) {
+			t.Errorf("expected %d nodes in profile, saw %d", len(counts), len(p.nodes))
+		}
+	})
+
+	t.Run("profile", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := p.write(&buf); err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		if len(lines) != 6 || !strings.HasPrefix(lines[0], "tea profile: 4 nodes") {
+			t.Fatalf("unexpected profile:\n%s", buf.String())
+		}
+		// A is replayed the most, so it comes first.
+		if !strings.HasSuffix(lines[2], "  A") {
+			t.Errorf("expected the most replayed node first:\n%s", buf.String())
+		}
+	})
+
+	t.Run("trace", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := p.trace(&buf); err != nil {
+			t.Fatal(err)
+		}
+		var trace struct {
+			TraceEvents []traceEvent `json:"traceEvents"`
+		}
+		if err := json.Unmarshal(buf.Bytes(), &trace); err != nil {
+			t.Fatalf("trace is not valid JSON: %v", err)
+		}
+		cats := make(map[string]int)
+		for _, e := range trace.TraceEvents {
+			cats[e.Cat]++
+		}
+		if cats["exec"] != 4 || cats["run"] != 4 || cats["replay"] != 4 {
+			t.Errorf("unexpected trace events: %v", cats)
+		}
+	})
+}
@@ -0,0 +1,154 @@
+package tea
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recorder is a testing.TB that records the outcome of a test instead of
+// reporting it. A recorder lets tea inspect the failures of a test without
+// failing the test that is running it. Methods of testing.TB that do not
+// report an outcome, such as Cleanup and TempDir, are passed through to the
+// parent testing.TB.
+type recorder struct {
+	testing.TB
+
+	mu       sync.Mutex
+	logs     []string
+	failures []string
+	failed   bool
+	skipped  bool
+}
+
+// capture runs the function f against a new recorder having the provided
+// parent. f is run in its own goroutine, so that calls to FailNow, Fatal or
+// SkipNow stop only f, and panics in f are recorded as failures.
+func capture(parent testing.TB, f func(testing.TB)) *recorder {
+	r := &recorder{TB: parent}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer func() {
+			if p := recover(); p != nil {
+				r.Errorf("panic: %v\n%s", p, debug.Stack())
+			}
+		}()
+		f(r)
+	}()
+	<-done
+	return r
+}
+
+// caller finds the file and line of the code that called into a recorder or
+// a reporter, skipping their own methods, formatted as go test would format
+// it.
+func caller() string {
+	pc := make([]uintptr, 16)
+	n := runtime.Callers(2, pc)
+	frames := runtime.CallersFrames(pc[:n])
+	for {
+		frame, more := frames.Next()
+		if !strings.Contains(frame.Function, ".(*recorder).") && !strings.Contains(frame.Function, ".(*reporter).") {
+			return fmt.Sprintf("%s:%d", filepath.Base(frame.File), frame.Line)
+		}
+		if !more {
+			return "???:1"
+		}
+	}
+}
+
+func (r *recorder) log(s string) {
+	s = caller() + ": " + strings.TrimSuffix(s, "\n")
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.logs = append(r.logs, s)
+}
+
+func (r *recorder) fail(s string) {
+	s = strings.TrimSuffix(s, "\n")
+	line := caller() + ": " + s
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.logs = append(r.logs, line)
+	r.failures = append(r.failures, s)
+	r.failed = true
+}
+
+func (r *recorder) Log(args ...interface{}) { r.log(fmt.Sprintln(args...)) }
+
+func (r *recorder) Logf(format string, args ...interface{}) { r.log(fmt.Sprintf(format, args...)) }
+
+func (r *recorder) Error(args ...interface{}) { r.fail(fmt.Sprintln(args...)) }
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.fail(fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatal(args ...interface{}) {
+	r.Error(args...)
+	r.FailNow()
+}
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.Errorf(format, args...)
+	r.FailNow()
+}
+
+func (r *recorder) Fail() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failed = true
+}
+
+func (r *recorder) FailNow() {
+	r.Fail()
+	runtime.Goexit()
+}
+
+func (r *recorder) Failed() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.failed
+}
+
+func (r *recorder) Skip(args ...interface{}) {
+	r.Log(args...)
+	r.SkipNow()
+}
+
+func (r *recorder) Skipf(format string, args ...interface{}) {
+	r.Logf(format, args...)
+	r.SkipNow()
+}
+
+func (r *recorder) SkipNow() {
+	r.mu.Lock()
+	r.skipped = true
+	r.mu.Unlock()
+	runtime.Goexit()
+}
+
+func (r *recorder) Skipped() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.skipped
+}
+
+func (r *recorder) Helper() {}
+
+// replay writes everything logged to the recorder to the provided
+// testing.TB as log lines, without failing it. Each line begins with the file
+// and line at which it was logged.
+func (r *recorder) replay(t testing.TB) {
+	t.Helper()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, s := range r.logs {
+		t.Log(s)
+	}
+}
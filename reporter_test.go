@@ -0,0 +1,57 @@
+package tea
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestCapture(t *testing.T) {
+	t.Run("fatal stops only the captured function", func(t *testing.T) {
+		reached := false
+		rec := capture(t, func(t testing.TB) {
+			t.Fatalf("stop here")
+			reached = true
+		})
+		if reached {
+			t.Errorf("expected Fatalf to stop the captured function")
+		}
+		if !rec.Failed() {
+			t.Errorf("expected recorder to have failed")
+		}
+		if len(rec.failures) != 1 || rec.failures[0] != "stop here" {
+			t.Errorf("unexpected recorded failures: %q", rec.failures)
+		}
+	})
+
+	t.Run("skips are recorded", func(t *testing.T) {
+		rec := capture(t, func(t testing.TB) { t.Skip("not today") })
+		if !rec.Skipped() || rec.Failed() {
+			t.Errorf("expected recorder to be skipped and not failed")
+		}
+	})
+
+	t.Run("messages record their caller", func(t *testing.T) {
+		var line int
+		rec := capture(t, func(t testing.TB) {
+			_, _, line, _ = runtime.Caller(0)
+			t.Log("logged")
+			t.Fatal("fatal")
+		})
+		want := []string{
+			fmt.Sprintf("reporter_test.go:%d: logged", line+1),
+			fmt.Sprintf("reporter_test.go:%d: fatal", line+2),
+		}
+		if !reflect.DeepEqual(rec.logs, want) {
+			t.Errorf("expected logs %q, saw %q", want, rec.logs)
+		}
+	})
+
+	t.Run("panics are recorded as failures", func(t *testing.T) {
+		rec := capture(t, func(t testing.TB) { panic("oh no") })
+		if !rec.Failed() {
+			t.Errorf("expected a panic to be recorded as a failure")
+		}
+	})
+}
@@ -0,0 +1,81 @@
+package tea
+
+import (
+	"time"
+)
+
+// Retry sets a retry policy on a tree node, returning the node so that calls
+// may be chained onto Child. Retry is intended for steps that fail now and
+// then, such as those talking to an eventually consistent system. The node's
+// test is run up to attempts times, until an attempt passes. Each attempt is
+// run with a fresh clone of the node's test, loaded from the same environment
+// produced by its parents. Before the second attempt we wait for backoff,
+// doubling the wait before each attempt after that.
+//
+// Every attempt is logged. The cleanup of a failed attempt's test, performed
+// by its After or AfterTB method, is done before the next attempt is run. If
+// an attempt after the first passes, the node passes, and is logged and
+// reported as having passed after that number of attempts. If every attempt
+// fails, the node fails.
+//
+// Since the failure of an attempt must be kept from the Go test running it,
+// the node's test must implement TB. Retry has no effect on a node marked with
+// XFail.
+func (t *Tree) Retry(attempts int, backoff time.Duration) *Tree {
+	if attempts < 1 {
+		attempts = 1
+	}
+	t.retry = &retry{attempts: attempts, backoff: backoff}
+	return t
+}
+
+// retry is the retry policy of a tree node.
+type retry struct {
+	attempts int
+	backoff  time.Duration
+}
+
+// run runs a test until it passes or the policy's attempts are exhausted.
+// Each attempt after the first is run on a test created by fresh, once the
+// test of the failed attempt has been cleaned up. run returns the test value
+// of the final attempt and the number of attempts made.
+func (p *retry) run(t tester, tree *Tree, test Test, fresh func() (Test, error)) (Test, int) {
+	delay := p.backoff
+	for attempt := 1; ; attempt++ {
+		tb, ok := tree.decorate(test).(TB)
+		if !ok {
+			t.Errorf("%v: %s has a retry policy but does not implement tea.TB", PlanError, tree.name)
+			return test, attempt
+		}
+
+		rec := capture(t, tb.RunTB)
+		if rec.Skipped() {
+			rec.replay(t)
+			t.SkipNow()
+		}
+		if !rec.Failed() {
+			rec.replay(t)
+			if attempt > 1 {
+				t.Logf("tea passed after %d attempts", attempt)
+			}
+			return test, attempt
+		}
+
+		t.Logf("tea attempt %d of %d failed:", attempt, p.attempts)
+		rec.replay(t)
+		if attempt == p.attempts {
+			t.Errorf("tea failed after %d attempts", attempt)
+			return test, attempt
+		}
+
+		t.after(test)
+		time.Sleep(delay)
+		delay *= 2
+
+		var err error
+		if test, err = fresh(); err != nil {
+			t.Errorf("test plan failed: %s", err)
+			return test, attempt
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package tea
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// flaky is a test that fails until it has been run a number of times.
+type flaky struct {
+	Runs  int `tea:"save"`
+	fails int
+	runs  *int
+}
+
+func (test *flaky) Run(t *testing.T) { test.RunTB(t) }
+
+func (test *flaky) RunTB(t testing.TB) {
+	*test.runs++
+	test.Runs = *test.runs
+	if *test.runs <= test.fails {
+		t.Errorf("flaked on run %d", *test.runs)
+	}
+}
+
+// cleanedFlaky is a flaky test that counts its cleanups.
+type cleanedFlaky struct {
+	flaky
+	cleanups *int
+}
+
+func (test *cleanedFlaky) AfterTB(t testing.TB) { *test.cleanups++ }
+
+type checkRuns struct {
+	Runs   int `tea:"load"`
+	expect int
+}
+
+func (test *checkRuns) Run(t *testing.T) {
+	if test.Runs != test.expect {
+		t.Errorf("expected to load the final attempt having %d runs, loaded %d", test.expect, test.Runs)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	t.Run("passes after retries", func(t *testing.T) {
+		var runs int
+		root := New(&saveX{X: 1})
+		root.Child(&flaky{fails: 2, runs: &runs}).Retry(3, 0).Child(&checkRuns{expect: 4})
+		new(runner).run(testT{t}, root)
+		if runs != 4 {
+			t.Errorf("expected 3 attempts of the flaky node and 1 more as an ancestor, saw %d", runs)
+		}
+	})
+
+	t.Run("fails when attempts are exhausted", func(t *testing.T) {
+		var runs int
+		root := New(&saveX{X: 1})
+		root.Child(&flaky{fails: 5, runs: &runs}).Retry(2, 0)
+
+		var buf bytes.Buffer
+		if Standalone(&buf, root) {
+			t.Errorf("expected tree to fail:\n%s", buf.String())
+		}
+		for _, line := range []string{
+			"tea attempt 1 of 2 failed:",
+			"flaked on run 2",
+			"tea failed after 2 attempts",
+		} {
+			if !strings.Contains(buf.String(), line) {
+				t.Errorf("expected output to contain %q:\n%s", line, buf.String())
+			}
+		}
+		if runs != 2 {
+			t.Errorf("expected 2 attempts, saw %d", runs)
+		}
+	})
+	t.Run("cleans up failed attempts", func(t *testing.T) {
+		var runs, cleanups int
+		root := New(&saveX{X: 1})
+		root.Child(&cleanedFlaky{flaky: flaky{fails: 2, runs: &runs}, cleanups: &cleanups}).Retry(3, 0)
+		new(runner).run(testT{t}, root)
+		if cleanups != 3 {
+			t.Errorf("expected 2 failed attempts and the passing attempt to be cleaned up, saw %d cleanups", cleanups)
+		}
+	})
+
+	t.Run("reports attempts", func(t *testing.T) {
+		var runs int
+		root := New(&saveX{X: 1})
+		root.Child(&flaky{fails: 1, runs: &runs}).Retry(3, 0)
+		s := newSuite(Config{}, []*Tree{root}, nil)
+		s.run(testT{t}, root)
+
+		var buf bytes.Buffer
+		s.report.write(&buf)
+		want := "tea: 1 tree, 2 nodes: 2 passed, 0 failed, 0 skipped\ntea: saveX/flaky passed after 2 attempts\n"
+		if out := buf.String(); out != want {
+			t.Errorf("unexpected report: %q", out)
+		}
+	})
+}
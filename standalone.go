@@ -0,0 +1,265 @@
+package tea
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Standalone runs trees of tests outside of go test, such as from the main
+// package of a normal binary, writing the results to w in the same format as
+// go test -v. Standalone reports whether every test passed. The trees are run
+// just as Run would run them, with the same semantics for saving, loading and
+// matching fields, skipping the descendants of failed tests and calling After
+// methods, and with the same command-line flags, so long as the binary calls
+// flag.Parse.
+//
+// Since there is no *testing.T outside of go test, every test in the trees
+// must implement TB, and tests having cleanup must implement AfterTB. A
+// typical main function would exit with a non-zero status on failure:
+//
+//	func main() {
+//		flag.Parse()
+//		if !tea.Standalone(os.Stdout, smokeTest()) {
+//			os.Exit(1)
+//		}
+//	}
+func Standalone(w io.Writer, trees ...*Tree) bool {
+	root := &reporter{w: w}
+	for _, tree := range trees {
+		r := newRunner()
+		r.setFocus(root, tree)
+		r.setFailFast(tree)
+		r.run(root, tree)
+		r.checkPath(root, tree)
+		r.fixtures.closeAll()
+	}
+	if err := activeProfile().flush(); err != nil {
+		fmt.Fprintln(w, err)
+		root.Fail()
+	}
+	if root.Failed() {
+		fmt.Fprintln(w, "FAIL")
+		return false
+	}
+	fmt.Fprintln(w, "PASS")
+	return true
+}
+
+// reporter is a tester that reports the outcome of tests directly to an
+// io.Writer, for running trees outside of go test. The methods of testing.TB
+// that reporter does not implement panic if called.
+type reporter struct {
+	// testing.TB has unexported methods, so the only way to implement it
+	// outside of package testing is by embedding it.
+	testing.TB
+
+	w      io.Writer
+	name   string
+	depth  int
+	parent *reporter
+
+	mu       sync.Mutex
+	failed   bool
+	skipped  bool
+	cleanups []func()
+	seen     map[string]int
+	ctx      context.Context
+	cancel   context.CancelFunc
+}
+
+func (r *reporter) run(name string, f func(tester)) bool {
+	// like go test, give each subtest a unique name.
+	name = strings.ReplaceAll(name, " ", "_")
+	r.mu.Lock()
+	if r.seen == nil {
+		r.seen = make(map[string]int)
+	}
+	if n := r.seen[name]; n > 0 {
+		r.seen[name]++
+		name = fmt.Sprintf("%s#%02d", name, n)
+	} else {
+		r.seen[name] = 1
+	}
+	r.mu.Unlock()
+
+	child := &reporter{w: r.w, name: name, parent: r}
+	if r.name != "" {
+		child.name = r.name + "/" + name
+		child.depth = r.depth + 1
+	}
+	child.ctx, child.cancel = context.WithCancel(context.Background())
+
+	fmt.Fprintf(r.w, "=== RUN   %s\n", child.name)
+	start := time.Now()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer child.cleanup()
+		defer func() {
+			if p := recover(); p != nil {
+				child.Errorf("panic: %v\n%s", p, debug.Stack())
+			}
+		}()
+		f(child)
+	}()
+	<-done
+
+	status := "PASS"
+	switch {
+	case child.Failed():
+		status = "FAIL"
+	case child.Skipped():
+		status = "SKIP"
+	}
+	fmt.Fprintf(r.w, "%s--- %s: %s (%.2fs)\n", strings.Repeat("    ", child.depth), status, child.name, time.Since(start).Seconds())
+	return !child.Failed()
+}
+
+func (r *reporter) test(test Test) { runTB(r, test) }
+
+func (r *reporter) after(test Test) { afterTB(r, test) }
+
+// cleanup calls the functions registered with Cleanup, in the reverse order
+// of their registration.
+func (r *reporter) cleanup() {
+	r.cancel()
+	r.mu.Lock()
+	cleanups := r.cleanups
+	r.cleanups = nil
+	r.mu.Unlock()
+	for i := len(cleanups) - 1; i >= 0; i-- {
+		cleanups[i]()
+	}
+}
+
+// log writes a line of output as go test -v would, prefixed by the file and
+// line that logged it and indented by the depth of the reporter.
+func (r *reporter) log(s string) {
+	indent := strings.Repeat("    ", r.depth+1)
+	s = caller() + ": " + strings.TrimSuffix(s, "\n")
+	s = strings.ReplaceAll(s, "\n", "\n"+indent+"    ")
+	fmt.Fprintf(r.w, "%s%s\n", indent, s)
+}
+
+func (r *reporter) Log(args ...interface{}) { r.log(fmt.Sprintln(args...)) }
+
+func (r *reporter) Logf(format string, args ...interface{}) { r.log(fmt.Sprintf(format, args...)) }
+
+func (r *reporter) Error(args ...interface{}) {
+	r.Log(args...)
+	r.Fail()
+}
+
+func (r *reporter) Errorf(format string, args ...interface{}) {
+	r.Logf(format, args...)
+	r.Fail()
+}
+
+func (r *reporter) Fatal(args ...interface{}) {
+	r.Error(args...)
+	r.FailNow()
+}
+
+func (r *reporter) Fatalf(format string, args ...interface{}) {
+	r.Errorf(format, args...)
+	r.FailNow()
+}
+
+// Fail marks the reporter and all of its ancestors as having failed.
+func (r *reporter) Fail() {
+	if r.parent != nil {
+		r.parent.Fail()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failed = true
+}
+
+func (r *reporter) FailNow() {
+	r.Fail()
+	runtime.Goexit()
+}
+
+func (r *reporter) Failed() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.failed
+}
+
+func (r *reporter) Skip(args ...interface{}) {
+	r.Log(args...)
+	r.SkipNow()
+}
+
+func (r *reporter) Skipf(format string, args ...interface{}) {
+	r.Logf(format, args...)
+	r.SkipNow()
+}
+
+func (r *reporter) SkipNow() {
+	r.mu.Lock()
+	r.skipped = true
+	r.mu.Unlock()
+	runtime.Goexit()
+}
+
+func (r *reporter) Skipped() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.skipped
+}
+
+func (r *reporter) Helper() {}
+
+func (r *reporter) Name() string { return r.name }
+
+func (r *reporter) Cleanup(f func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cleanups = append(r.cleanups, f)
+}
+
+func (r *reporter) Context() context.Context { return r.ctx }
+
+func (r *reporter) TempDir() string {
+	dir, err := ioutil.TempDir("", "tea")
+	if err != nil {
+		r.Fatalf("TempDir: %v", err)
+	}
+	r.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+func (r *reporter) Setenv(key, value string) {
+	prev, ok := os.LookupEnv(key)
+	if err := os.Setenv(key, value); err != nil {
+		r.Fatalf("Setenv: %v", err)
+	}
+	r.Cleanup(func() {
+		if ok {
+			os.Setenv(key, prev)
+		} else {
+			os.Unsetenv(key)
+		}
+	})
+}
+
+func (r *reporter) Chdir(dir string) {
+	prev, err := os.Getwd()
+	if err != nil {
+		r.Fatalf("Chdir: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		r.Fatalf("Chdir: %v", err)
+	}
+	r.Cleanup(func() { os.Chdir(prev) })
+}
@@ -0,0 +1,104 @@
+package tea
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// named is a passing test having a name of our choosing.
+type named struct {
+	name string
+}
+
+func (test *named) String() string { return test.name }
+
+func (test *named) Run(t *testing.T) { test.RunTB(t) }
+
+func (test *named) RunTB(t testing.TB) {}
+
+func TestStandalone(t *testing.T) {
+	t.Run("passing tree", func(t *testing.T) {
+		var n int
+		root := New(&saveX{X: 1})
+		root.Child(&teardownCount{n: &n}).Child(&checkX{expect: 1})
+
+		var buf bytes.Buffer
+		if !Standalone(&buf, root) {
+			t.Errorf("expected tree to pass:\n%s", buf.String())
+		}
+		if n != 2 {
+			t.Errorf("expected 2 teardowns, saw %d", n)
+		}
+		if !strings.HasSuffix(buf.String(), "PASS\n") {
+			t.Errorf("expected output to end in PASS:\n%s", buf.String())
+		}
+	})
+
+	t.Run("failing tree", func(t *testing.T) {
+		root := New(&saveX{X: 1})
+		root.Child(&checkX{expect: 2}).Child(&checkX{expect: 1})
+
+		var buf bytes.Buffer
+		if Standalone(&buf, root) {
+			t.Errorf("expected tree to fail:\n%s", buf.String())
+		}
+		out := buf.String()
+		for _, line := range []string{
+			"--- FAIL: saveX/checkX",
+			"expected X to be 2, is 1 instead",
+			"--- SKIP: saveX/checkX/checkX",
+			"tea skipped: dependency failed",
+			"tea path: saveX/checkX",
+		} {
+			if !strings.Contains(out, line) {
+				t.Errorf("expected output to contain %q:\n%s", line, out)
+			}
+		}
+	})
+
+	t.Run("log format", func(t *testing.T) {
+		root := New(&saveX{X: 1})
+		root.Child(&checkX{expect: 2})
+
+		var buf bytes.Buffer
+		Standalone(&buf, root)
+		// like go test -v, logs are indented by depth and prefixed by the
+		// file and line that logged them.
+		want := regexp.MustCompile(`(?m)^        fails_test\.go:\d+: expected X to be 2, is 1 instead$`)
+		if !want.MatchString(buf.String()) {
+			t.Errorf("expected output to match %q:\n%s", want, buf.String())
+		}
+	})
+
+	t.Run("subtest names", func(t *testing.T) {
+		root := New(&saveX{X: 1})
+		root.Child(&named{name: "check X"})
+		root.Child(&named{name: "check X"})
+
+		var buf bytes.Buffer
+		if !Standalone(&buf, root) {
+			t.Fatalf("expected tree to pass:\n%s", buf.String())
+		}
+		for _, line := range []string{
+			"--- PASS: saveX/check_X ",
+			"--- PASS: saveX/check_X#01 ",
+		} {
+			if !strings.Contains(buf.String(), line) {
+				t.Errorf("expected output to contain %q:\n%s", line, buf.String())
+			}
+		}
+	})
+
+	t.Run("tests must implement TB", func(t *testing.T) {
+		var log []string
+		var buf bytes.Buffer
+		if Standalone(&buf, New(&record{name: "A", log: &log})) {
+			t.Errorf("expected a test not implementing TB to fail")
+		}
+		if len(log) != 0 {
+			t.Errorf("expected test not to be run, saw %v", log)
+		}
+	})
+}
@@ -0,0 +1,102 @@
+package tea
+
+import (
+	"testing"
+)
+
+// tester is the testing context in which tea runs a tree. Since Test values
+// may be written against either a *testing.T or any testing.TB, a tester
+// knows how to run each kind of test value in its context.
+type tester interface {
+	testing.TB
+
+	// run runs f as a subtest having the provided name, reporting whether f
+	// succeeded.
+	run(name string, f func(tester)) bool
+
+	// test runs a single test value.
+	test(Test)
+
+	// after performs the cleanup of a single test value, if it has any.
+	after(Test)
+}
+
+// testT is a tester for running a tree in a *testing.T, as Run does.
+type testT struct {
+	*testing.T
+}
+
+func (t testT) run(name string, f func(tester)) bool {
+	return t.T.Run(name, func(t *testing.T) { f(testT{t}) })
+}
+
+func (t testT) test(test Test) { test.Run(t.T) }
+
+func (t testT) after(test Test) {
+	switch a := subject(test).(type) {
+	case After:
+		a.After(t.T)
+	case AfterTB:
+		a.AfterTB(t.T)
+	}
+}
+
+// parallelT is a tester for running a tree in parallel with the other
+// subtests of a *testing.T, as RunRegistered does when configured to run
+// trees in parallel. Only the subtest of the tree's root is parallel; its
+// descendants are run one at a time, as they are by testT.
+type parallelT struct {
+	testT
+}
+
+func (t parallelT) run(name string, f func(tester)) bool {
+	return t.T.Run(name, func(t *testing.T) {
+		t.Parallel()
+		f(testT{t})
+	})
+}
+
+// testB is a tester for running a tree in a *testing.B, as Bench does.
+type testB struct {
+	*testing.B
+}
+
+func (b testB) run(name string, f func(tester)) bool {
+	return b.B.Run(name, func(b *testing.B) { f(testB{b}) })
+}
+
+func (b testB) test(test Test) { runTB(b.B, test) }
+
+func (b testB) after(test Test) { afterTB(b.B, test) }
+
+// afterAny performs the cleanup of a test value in any testing context, by its
+// After method in a *testing.T and by its AfterTB method otherwise.
+func afterAny(t testing.TB, test Test) {
+	if tt, ok := t.(*testing.T); ok {
+		testT{tt}.after(test)
+		return
+	}
+	afterTB(t, test)
+}
+
+// runTB runs a test value in a testing context other than a *testing.T. The
+// test must implement TB.
+func runTB(t testing.TB, test Test) {
+	tb, ok := test.(TB)
+	if !ok {
+		t.Fatalf("%v: %s does not implement tea.TB, so it cannot be run outside of a *testing.T", PlanError, parseName(test))
+	}
+	tb.RunTB(t)
+}
+
+// afterTB performs the cleanup of a test value in a testing context other
+// than a *testing.T. If the test has an After method, it must also have an
+// AfterTB method.
+func afterTB(t testing.TB, test Test) {
+	switch a := subject(test).(type) {
+	case AfterTB:
+		a.AfterTB(t)
+	case After:
+		t.Errorf("%v: %s implements tea.After but not tea.AfterTB, so it cannot be cleaned up outside of a *testing.T", PlanError, parseName(test))
+	}
+}
@@ -12,6 +12,19 @@ type Test interface {
 	Run(*testing.T)
 }
 
+// TB is an optional interface for Test values that are able to run against
+// any testing.TB instead of only a *testing.T. Tests must implement TB to be
+// run outside of a *testing.T, as they are by Bench. tea is also only able to
+// isolate the outcome of a test from the Go test that is running it, as it
+// does for nodes marked with XFail, when the test implements TB. A type
+// implementing TB would typically implement Run by calling RunTB:
+//
+//	func (test *testHits) Run(t *testing.T) { test.RunTB(t) }
+type TB interface {
+	Test
+	RunTB(testing.TB)
+}
+
 // After defines the interface used for performing test cleanup. If a Test
 // value also implements After, that test's After method will be called after
 // all tests are run. Tests in a sequence will have their After methods called
@@ -21,6 +34,53 @@ type After interface {
 	After(*testing.T)
 }
 
+// AfterTB is the counterpart of After for Test values implementing TB. When
+// tea runs a test outside of a *testing.T, such as in a benchmark, its
+// cleanup is performed by its AfterTB method. A test implementing both After
+// and AfterTB has only its After method called when run in a *testing.T.
+type AfterTB interface {
+	AfterTB(testing.TB)
+}
+
+// AfterAll is an optional interface for Test values having cleanup that is to
+// be performed once, after a node and its entire subtree have finished,
+// rather than after every run of the test. Since every descendant of a node
+// replays the node's test, a test's After method is called once for the node
+// itself and once more for each of its descendants; AfterAll is called only on
+// the value of the test that was run for the node itself, after the cleanup of
+// all of its descendants and before the cleanup of its ancestors. AfterAll is
+// called even if the node fails, so long as its test returned from Run.
+type AfterAll interface {
+	AfterAll(testing.TB)
+}
+
+// wrapper is implemented by Test values that wrap another Test value, such as
+// the tests created by Fails. The fields of the wrapped test are saved and
+// loaded in place of the fields of the wrapper, and the wrapped test is
+// cloned along with its wrapper.
+type wrapper interface {
+	Test
+
+	// unwrap returns the wrapped test.
+	unwrap() Test
+
+	// rewrap returns a copy of the wrapper that wraps the provided test
+	// instead.
+	rewrap(Test) Test
+}
+
+// subject returns the innermost test wrapped by a test, or the test itself if
+// it is not a wrapper.
+func subject(test Test) Test {
+	for {
+		w, ok := test.(wrapper)
+		if !ok {
+			return test
+		}
+		test = w.unwrap()
+	}
+}
+
 func fail(t string, args ...interface{}) Test {
 	return failure{cause: fmt.Errorf(t, args...)}
 }
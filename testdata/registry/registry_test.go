@@ -0,0 +1,35 @@
+// Package registry is a package whose tests are run by TestMainRunsRegistered
+// to check that trees registered with tea.Register are run by TestTea, as an
+// ordinary Go test, within the test binary's coverage profile.
+package registry
+
+import (
+	"os"
+	"testing"
+
+	"github.com/jordanorelli/tea"
+)
+
+type saveX struct {
+	X int `tea:"save"`
+}
+
+func (test *saveX) Run(t *testing.T) { test.X = 1 }
+
+type checkX struct {
+	X int `tea:"load"`
+}
+
+func (test *checkX) Run(t *testing.T) {
+	if test.X != 1 {
+		t.Errorf("expected to load X=1, loaded X=%d", test.X)
+	}
+}
+
+var _ = tea.Register(tea.New(&saveX{})).Child(&checkX{})
+
+func TestMain(m *testing.M) {
+	os.Exit(tea.Main(m, tea.Config{Report: os.Stdout}))
+}
+
+func TestTea(t *testing.T) { tea.RunRegistered(t) }
@@ -0,0 +1,19 @@
+// Package unrun is a package whose tests are run by TestMainRunsRegistered to
+// check that Main fails a test binary in which trees are registered but no Go
+// test calls tea.RunRegistered.
+package unrun
+
+import (
+	"os"
+	"testing"
+
+	"github.com/jordanorelli/tea"
+)
+
+var _ = tea.Register(tea.New(tea.Pass))
+
+func TestMain(m *testing.M) {
+	os.Exit(tea.Main(m, tea.Config{}))
+}
+
+func TestNothing(t *testing.T) {}
@@ -1,9 +1,11 @@
 package tea
 
 import (
+	"fmt"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 // Run runs a tree of tests. Tests will be run recursively starting at the
@@ -16,57 +18,389 @@ import (
 // Test values. You would then call Run just once, by supplying to Run the root
 // node of your tree.
 func Run(t *testing.T, tree *Tree) {
-	t.Run(tree.name, func(t *testing.T) {
-		history, _ := exec(t, tree)
-		for _, test := range history {
-			if a, ok := test.(After); ok {
-				a.After(t)
+	r := newRunner()
+	defer r.ownFixtures(tree).closeAll()
+	r.setFocus(t, tree)
+	r.setFailFast(tree)
+	r.run(testT{t}, tree)
+	r.checkPath(t, tree)
+	if err := r.profile.flush(); err != nil {
+		t.Error(err)
+	}
+}
+
+// runner holds the configuration for a single call to Run.
+type runner struct {
+	// path is the path ID of the only node to be run, if non-empty. The
+	// ancestors of that node are always run, since they are its
+	// prerequisites. If descend is true, its descendants are run as well.
+	path    string
+	descend bool
+
+	// found is true once the node having the path ID has been reached.
+	found bool
+
+	// include and exclude are the labels used to select nodes to be run.
+	include []string
+	exclude []string
+
+	// focus is true if only focused nodes are to be run.
+	focus bool
+
+	// passed records whether each node that has been run or skipped passed.
+	passed map[*Tree]bool
+
+	// fixtures are the shared fixtures that have been set up.
+	fixtures *fixtures
+
+	// report, if not nil, receives the outcome of every node.
+	report *report
+
+	// profile, if not nil, receives the time taken to run every node.
+	profile *profile
+
+	// failFast is true if no more nodes are to be run after a node fails.
+	// failure records the first node to fail.
+	failFast bool
+	failure  *firstFail
+}
+
+// newRunner creates a runner configured by tea's command-line flags.
+func newRunner() *runner {
+	r := new(runner)
+	r.setPath(*pathFlag)
+	r.setLabels()
+	r.profile = activeProfile()
+	return r
+}
+
+// setPath sets the path ID of the node to be run. A path ending in /...
+// selects the node's descendants as well as the node itself.
+func (r *runner) setPath(path string) {
+	if strings.HasSuffix(path, "/...") {
+		r.path = strings.TrimSuffix(path, "/...")
+		r.descend = true
+		return
+	}
+	r.path = path
+	r.descend = false
+}
+
+// selected determines whether a given tree node should be run at all.
+func (r *runner) selected(tree *Tree) bool {
+	if r.path == "" {
+		return true
+	}
+	id := tree.ID()
+	if id == r.path || strings.HasPrefix(r.path, id+"/") {
+		return true
+	}
+	return r.descend && strings.HasPrefix(id, r.path+"/")
+}
+
+// reached records that a selected node has been reached by the runner, either
+// to be run or to be skipped.
+func (r *runner) reached(tree *Tree) {
+	if r.path != "" && !r.found && tree.ID() == r.path {
+		r.found = true
+	}
+}
+
+// checkPath fails a run in which no node has the runner's path ID. A path ID
+// naming a node of another tree is not an error, since go test may run many
+// trees with the same flags.
+func (r *runner) checkPath(t testing.TB, tree *Tree) {
+	if r.path == "" || r.found {
+		return
+	}
+	root := tree.ID()
+	if r.path == root || strings.HasPrefix(r.path, root+"/") {
+		t.Errorf("tea: no node has path ID %q", r.path)
+	}
+}
+
+// skipReason determines whether a selected tree node is to be reported as
+// skipped instead of being run, returning the status with which to report it
+// and the reason for skipping it.
+func (r *runner) skipReason(tree *Tree) (status, string) {
+	if reason := r.excluded(tree); reason != "" {
+		return statusExcluded, reason
+	}
+	if reason := r.unfocused(tree); reason != "" {
+		return statusSkipped, reason
+	}
+	if tree.pending {
+		return statusSkipped, "tea skipped: pending"
+	}
+	if r.joinFailed(tree) {
+		return statusSkipped, "tea skipped: dependency failed"
+	}
+	return statusSkipped, ""
+}
+
+// ownFixtures returns the runner's fixtures, giving the runner its own set of
+// fixtures for running the provided tree if it does not yet have one. Nodes
+// that are not selected are never run, so they do not hold on to fixtures.
+// Every other node releases its fixtures from within its own subtest, which
+// may finish after run has returned if the subtest is parallel.
+func (r *runner) ownFixtures(tree *Tree) *fixtures {
+	if r.fixtures == nil {
+		r.fixtures = &fixtures{trees: []*Tree{tree}, visits: r.selected}
+	}
+	return r.fixtures
+}
+
+// record records whether a node passed.
+func (r *runner) record(tree *Tree, passed bool) {
+	if r.passed == nil {
+		r.passed = make(map[*Tree]bool)
+	}
+	r.passed[tree] = passed
+}
+
+func (r *runner) run(t tester, tree *Tree) {
+	fixtures := r.ownFixtures(tree)
+	if !r.selected(tree) {
+		return
+	}
+	r.reached(tree)
+	if st, reason := r.skipReason(tree); reason != "" {
+		r.skip(t, tree, st, reason)
+		return
+	}
+	if first := r.firstFailure(); r.failFast && first != "" {
+		r.skip(t, tree, statusNotRun, fmt.Sprintf("tea skipped: fail-fast after the failure of %s", first))
+		return
+	}
+
+	t.run(tree.name, func(t tester) {
+		defer fixtures.release(tree)
+
+		// the node's outcome is reported before its children are run, since
+		// a failing child also fails its parent's test. If the node's test
+		// stops the node with FailNow or SkipNow, it is reported on exit.
+		x := new(execution)
+		reported := false
+		report := func() {
+			if !reported {
+				reported = true
+				r.report.add(tree, outcome(t, x), x.attempts)
+			}
+		}
+		defer report()
+
+		r.record(tree, false)
+		defer tree.afterSubtree(t)
+
+		if tree.before(t) {
+			x = r.exec(t, tree)
+			if x.inapplicable == "" {
+				defer afterAll(t, x.history[0])
+			}
+			if x.inapplicable == "" && !x.expected && !t.Failed() {
+				tree.checkInvariants(t, x.envs[tree])
+			}
+			teardown(t, x.history)
+			tree.after(t)
+		}
+		if x.inapplicable != "" {
+			for _, child := range tree.children {
+				r.skip(t, child, statusInapplicable, x.inapplicable)
 			}
+			t.Skip(x.inapplicable)
+		}
+		r.record(tree, !t.Failed() && !t.Skipped())
+		report()
+
+		if t.Failed() {
+			t.Logf("tea path: %s", tree.ID())
+			r.fail(tree)
+		}
+
+		if x.expected && !tree.xfail.proceed {
+			for _, child := range tree.children {
+				r.skip(t, child, statusSkipped, "tea skipped: dependency failed as expected")
+			}
+			return
 		}
 
 		if t.Failed() || t.Skipped() {
 			for _, child := range tree.children {
-				skip(t, child)
+				r.skip(t, child, statusSkipped, "tea skipped: dependency failed")
 			}
 			return
 		}
 
 		for _, child := range tree.children {
-			Run(t, child)
+			r.run(t, child)
+		}
+		for _, child := range tree.generate(x.history[0]) {
+			r.run(t, child)
 		}
 	})
 }
 
+// execution is the outcome of running a sequence of tree nodes.
+type execution struct {
+	// history is the tests that were run, from the most recently run test to
+	// the first.
+	history []Test
+
+	// envs is the environment produced by each node that was run.
+	envs map[*Tree]*env
+
+	// expected is true if the last node failed as expected, having been
+	// marked with XFail.
+	expected bool
+
+	// inapplicable is the reason the sequence was stopped if a node's guard
+	// was not met, or empty if every guard was met.
+	inapplicable string
+
+	// spans are the times taken to run each node's test, in the order in
+	// which they were run.
+	spans []span
+
+	// attempts is the number of attempts made at the last node, if the node
+	// has a retry policy.
+	attempts int
+}
+
 // exec runs the provided test and all of its ancestors in the provided testing
-// context. exec returns the environment produced by running these tests.
-func exec(t *testing.T, tree *Tree) ([]Test, *env) {
-	if tree == nil {
-		return nil, nil
+// context, loading them with the runner's fixtures.
+func (r *runner) exec(t tester, tree *Tree) *execution {
+	start := time.Now()
+	x := execNodes(t, tree.ancestry(), r.ownFixtures(tree).provide)
+	r.profile.add(tree, start, x.spans)
+	return x
+}
+
+// execNodes runs the tests of a sequence of tree nodes, such as the sequence
+// given by ancestry, in the provided testing context. Each node's test is run
+// in the environment produced by its parents. If prepare is not nil, it is
+// called with each node's test value and the environment produced by its
+// parents before the test is loaded. If a node's guard is not met, execNodes
+// stops without running the node.
+func execNodes(t tester, nodes []*Tree, prepare func(testing.TB, *Tree, Test, *env) error) *execution {
+	x := &execution{envs: make(map[*Tree]*env, len(nodes))}
+	for _, node := range nodes {
+		var (
+			e   *env
+			err error
+		)
+		if node.parent != nil {
+			e, err = parentEnv(node, x.envs)
+		}
+		if err == nil && !node.guard.met(e) {
+			x.inapplicable = fmt.Sprintf("tea not applicable: %s", node.guard.desc)
+			return x
+		}
+
+		// fresh creates a new clone of the node's test, loaded from the
+		// environment produced by its parents.
+		fresh := func() (Test, error) {
+			test := clone(node.test)
+			if prepare != nil {
+				if err := prepare(t, node, test, e); err != nil {
+					return test, err
+				}
+			}
+			return test, node.loadTest(test, e)
+		}
+
+		var test Test
+		if err == nil {
+			test, err = fresh()
+		} else {
+			test = clone(node.test)
+		}
+
+		x.expected = false
+		x.attempts = 0
+		start := time.Now()
+		switch {
+		case err != nil:
+			t.Errorf("test plan failed: %s", err)
+		case node.retry != nil && node.xfail == nil:
+			test, x.attempts = node.retry.run(t, node, test, fresh)
+		default:
+			x.expected = runTest(t, node, test)
+		}
+		x.spans = append(x.spans, span{node: node, start: start, dur: time.Since(start)})
+		x.history = append([]Test{test}, x.history...)
+		x.envs[node] = e.save(test)
+	}
+	return x
+}
+
+// loadTest loads a test value of a tree node from the environment e produced
+// by its parents. A root node has no parents, so its test is left as it is.
+func (t *Tree) loadTest(test Test, e *env) error {
+	if t.parent == nil {
+		return nil
+	}
+	return e.load(test)
+}
+
+// runTest runs a single test value belonging to the provided tree node,
+// wrapped with the node's decorators, returning whether the test failed as
+// expected.
+func runTest(t tester, tree *Tree, test Test) bool {
+	test = tree.decorate(test)
+	if tree.xfail != nil {
+		return tree.xfail.run(t, tree, test)
 	}
+	t.test(test)
+	return false
+}
 
-	if tree.parent == nil {
-		test := clone(tree.test)
-		test.Run(t)
-		return []Test{test}, mkenv(test)
+// teardown runs the After methods of the tests in a history, which is ordered
+// from the most recently run test to the first.
+func teardown(t tester, history []Test) {
+	for _, test := range history {
+		t.after(test)
 	}
+}
 
-	history, e := exec(t, tree.parent)
-	test := clone(tree.test)
-	if err := e.load(test); err != nil {
-		t.Errorf("test plan failed: %s", err)
-	} else {
-		test.Run(t)
+// outcome determines the status of a tree node that has been run, given the
+// execution of its test.
+func outcome(t testing.TB, x *execution) status {
+	switch {
+	case t.Failed():
+		return statusFailed
+	case x.inapplicable != "":
+		return statusInapplicable
+	case t.Skipped():
+		return statusSkipped
+	case x.expected:
+		return statusXFailed
 	}
-	return append([]Test{test}, history...), e.save(test)
+	return statusPassed
 }
 
-// skip skips the provided tree node as well as all of its children.
-func skip(t *testing.T, tree *Tree) {
-	t.Run(tree.name, func(t *testing.T) {
+// afterAll performs the subtree-once cleanup of a test value, if it has any.
+func afterAll(t testing.TB, test Test) {
+	if a, ok := subject(test).(AfterAll); ok {
+		a.AfterAll(t)
+	}
+}
+
+// skip skips the provided tree node as well as all of its children, giving
+// reason as the reason for skipping them and reporting them with the status
+// st. Nodes not selected by the runner are not reported.
+func (r *runner) skip(t tester, tree *Tree, st status, reason string) {
+	if !r.selected(tree) {
+		return
+	}
+	r.reached(tree)
+	r.record(tree, false)
+	t.run(tree.name, func(t tester) {
+		defer r.fixtures.release(tree)
+		defer r.report.add(tree, st, 0)
 		for _, child := range tree.children {
-			skip(t, child)
+			r.skip(t, child, st, reason)
 		}
-		t.Skip("tea skipped: dependency failed")
+		t.Skip(reason)
 	})
 }
 
@@ -88,7 +422,31 @@ type Tree struct {
 	test     Test
 	name     string
 	parent   *Tree
+	parents  []*Tree
 	children []*Tree
+	labels   []string
+	focused  bool
+	pending  bool
+	failFast bool
+	xfail    *xfail
+	guard    *guard
+	retry    *retry
+
+	invariants []invariant
+	decorators []Decorator
+	hooks      hooks
+	fixtures   []*fixture
+
+	// params are the names of the fields set on the node's test by Matrix or
+	// Pairwise, which are listed along with their values in the node's name.
+	params []string
+
+	// generated is true for nodes created at run time from the tests
+	// returned by a Generator. A generated node is not one of its parent's
+	// children, so its position amongst its same-named siblings is given by
+	// ordinal.
+	generated bool
+	ordinal   int
 }
 
 // Child creates a new Tree node as a child of the current tree node, returning
@@ -100,9 +458,150 @@ func (t *Tree) Child(test Test) *Tree {
 	return child
 }
 
+// ID returns the path ID of a tree node. A path ID is a slash-separated list
+// of the names of the nodes leading from the root of the tree to the node
+// itself. When a node shares its name with earlier siblings, its position
+// amongst those siblings is appended to its name in square brackets, such
+// that every node in a tree has a distinct path ID:
+//
+//	testInt/testIncr
+//	testInt/testIncr[1]
+//	testInt/testIncr[1]/testIncr
+//
+// Path IDs are derived only from the shape of the tree and the names of its
+// tests, so they are stable between runs and may be given to the -tea.path
+// flag to run a single node.
+func (t *Tree) ID() string {
+	segment := strings.ReplaceAll(t.name, "/", "_")
+	if t.parent == nil {
+		return segment
+	}
+
+	n := t.ordinal
+	if !t.generated {
+		n = t.parent.countNamed(t.name, t)
+	}
+	if n > 0 {
+		segment = fmt.Sprintf("%s[%d]", segment, n)
+	}
+	return t.parent.ID() + "/" + segment
+}
+
+// countNamed counts the children of a tree node having the provided name that
+// come before the child before, or all such children if before is nil.
+func (t *Tree) countNamed(name string, before *Tree) int {
+	n := 0
+	for _, child := range t.children {
+		if child == before {
+			break
+		}
+		if child.name == name {
+			n++
+		}
+	}
+	return n
+}
+
+// Name returns the name of a tree node, which is the name of its test.
+func (t *Tree) Name() string { return t.name }
+
+// Test returns the test value of a tree node. The test value is never run
+// directly; a clone of it is made each time the node is run. Callers should
+// not modify the returned test value.
+func (t *Tree) Test() Test { return t.test }
+
+// Parent returns the parent of a tree node, or nil if the node is the root of
+// its tree. For a node created by Join, Parent returns the primary parent
+// under which the node is run.
+func (t *Tree) Parent() *Tree { return t.parent }
+
+// Children returns the children of a tree node, in the order in which they
+// were added.
+func (t *Tree) Children() []*Tree {
+	children := make([]*Tree, len(t.children))
+	copy(children, t.children)
+	return children
+}
+
+// Len returns the number of nodes in the tree starting at a node, including
+// the node itself.
+func (t *Tree) Len() int {
+	n := 1
+	for _, child := range t.children {
+		n += child.Len()
+	}
+	return n
+}
+
+// Walk visits a node and all of its descendants in the order in which Run
+// would run them, calling fn for each node. If fn returns false, the
+// descendants of that node are not visited.
+func (t *Tree) Walk(fn func(*Tree) bool) {
+	if !fn(t) {
+		return
+	}
+	for _, child := range t.children {
+		child.Walk(fn)
+	}
+}
+
+// Paths enumerates the root-to-leaf paths passing through a node. Each path
+// begins at the root of the node's tree and ends at a leaf that is either the
+// node itself or one of its descendants, such that each path is a chain of
+// tests that Run would run in sequence.
+func (t *Tree) Paths() [][]*Tree {
+	var prefix []*Tree
+	for node := t.parent; node != nil; node = node.parent {
+		prefix = append([]*Tree{node}, prefix...)
+	}
+
+	var paths [][]*Tree
+	var walk func(*Tree, []*Tree)
+	walk = func(node *Tree, path []*Tree) {
+		path = append(path[:len(path):len(path)], node)
+		if len(node.children) == 0 {
+			paths = append(paths, path)
+			return
+		}
+		for _, child := range node.children {
+			walk(child, path)
+		}
+	}
+	walk(t, prefix)
+	return paths
+}
+
+// Find finds a node amongst a node and its descendants, given either its path
+// ID or its name. A path ID is preferred to a name; if no node has the given
+// path ID, Find returns the first node having the given name in the order of
+// Walk. Find returns nil if no node is found.
+func (t *Tree) Find(pathOrName string) *Tree {
+	var byID, byName *Tree
+	t.Walk(func(node *Tree) bool {
+		if byID != nil {
+			return false
+		}
+		if node.ID() == pathOrName {
+			byID = node
+			return false
+		}
+		if byName == nil && node.name == pathOrName {
+			byName = node
+		}
+		return true
+	})
+	if byID != nil {
+		return byID
+	}
+	return byName
+}
+
 // clone clones a test value, yielding a new test value that can be executed
 // and mutated such that the original is not mutated.
 func clone(t Test) Test {
+	if w, ok := t.(wrapper); ok {
+		return w.rewrap(clone(w.unwrap()))
+	}
 	srcV := reflect.ValueOf(t).Elem()
 	destV := reflect.New(srcV.Type())
 	destV.Elem().Set(srcV)
@@ -0,0 +1,217 @@
+package tea
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// record is a test that appends its name to a shared log when it is run,
+// letting us see which nodes of a tree were executed and in what order.
+type record struct {
+	name string
+	log  *[]string
+}
+
+func (r *record) String() string { return r.name }
+
+func (r *record) Run(t *testing.T) {
+	*r.log = append(*r.log, r.name)
+}
+
+// errorLog is a testing.TB that logs the errors reported to it instead of
+// failing.
+type errorLog struct {
+	testing.TB
+	errors []string
+}
+
+func (e *errorLog) Errorf(format string, args ...interface{}) {
+	e.errors = append(e.errors, fmt.Sprintf(format, args...))
+}
+
+func assertLog(t *testing.T, log []string, expected ...string) {
+	if !reflect.DeepEqual(log, expected) {
+		t.Errorf("expected tests to run as %v, saw %v instead", expected, log)
+	}
+}
+
+func TestPathIDs(t *testing.T) {
+	var log []string
+	root := New(&record{name: "A", log: &log})
+	b := root.Child(&record{name: "B", log: &log})
+	b2 := root.Child(&record{name: "B", log: &log})
+	c := b2.Child(&record{name: "C", log: &log})
+	slash := root.Child(&record{name: "GET /index", log: &log})
+
+	ids := map[*Tree]string{
+		root:  "A",
+		b:     "A/B",
+		b2:    "A/B[1]",
+		c:     "A/B[1]/C",
+		slash: "A/GET _index",
+	}
+	for node, expected := range ids {
+		if id := node.ID(); id != expected {
+			t.Errorf("expected node %s to have id %q, has %q instead", node.name, expected, id)
+		}
+	}
+}
+
+func TestRunPath(t *testing.T) {
+	var log []string
+	root := New(&record{name: "A", log: &log})
+	b := root.Child(&record{name: "B", log: &log})
+	b.Child(&record{name: "C", log: &log})
+	b2 := root.Child(&record{name: "B", log: &log})
+	b2.Child(&record{name: "C", log: &log})
+	root.Child(&record{name: "D", log: &log})
+
+	t.Run("no path runs everything", func(t *testing.T) {
+		log = nil
+		new(runner).run(testT{t}, root)
+		assertLog(t, log, "A", "A", "B", "A", "B", "C", "A", "B", "A", "B", "C", "A", "D")
+	})
+
+	t.Run("path runs node and ancestors", func(t *testing.T) {
+		log = nil
+		r := new(runner)
+		r.setPath("A/B[1]")
+		r.run(testT{t}, root)
+		assertLog(t, log, "A", "A", "B")
+	})
+
+	t.Run("path with descendants", func(t *testing.T) {
+		log = nil
+		r := new(runner)
+		r.setPath("A/B[1]/...")
+		r.run(testT{t}, root)
+		assertLog(t, log, "A", "A", "B", "A", "B", "C")
+	})
+
+	t.Run("unknown path fails", func(t *testing.T) {
+		log = nil
+		r := new(runner)
+		r.setPath("A/E")
+		r.run(testT{t}, root)
+		errs := new(errorLog)
+		r.checkPath(errs, root)
+		assertLog(t, log, "A")
+		if len(errs.errors) != 1 || errs.errors[0] != `tea: no node has path ID "A/E"` {
+			t.Errorf("expected an unknown path ID to fail the run, saw errors %q", errs.errors)
+		}
+	})
+
+	t.Run("path of another tree", func(t *testing.T) {
+		log = nil
+		r := new(runner)
+		r.setPath("Z/B")
+		r.run(testT{t}, root)
+		r.checkPath(t, root)
+		assertLog(t, log)
+	})
+}
+
+func TestIntrospection(t *testing.T) {
+	var log []string
+	root := New(&record{name: "A", log: &log})
+	b := root.Child(&record{name: "B", log: &log})
+	c := b.Child(&record{name: "C", log: &log})
+	d := b.Child(&record{name: "D", log: &log})
+	b2 := root.Child(&record{name: "B", log: &log})
+
+	t.Run("accessors", func(t *testing.T) {
+		if c.Name() != "C" || c.Parent() != b || root.Parent() != nil {
+			t.Errorf("unexpected name or parent for node %s", c.ID())
+		}
+		if c.Test().(*record).name != "C" {
+			t.Errorf("unexpected test for node %s", c.ID())
+		}
+		children := b.Children()
+		if len(children) != 2 || children[0] != c || children[1] != d {
+			t.Errorf("unexpected children of node %s: %v", b.ID(), children)
+		}
+	})
+
+	t.Run("len", func(t *testing.T) {
+		if n := root.Len(); n != 5 {
+			t.Errorf("expected tree to have 5 nodes, has %d", n)
+		}
+		if n := b.Len(); n != 3 {
+			t.Errorf("expected subtree to have 3 nodes, has %d", n)
+		}
+	})
+
+	t.Run("walk", func(t *testing.T) {
+		var ids []string
+		root.Walk(func(node *Tree) bool {
+			ids = append(ids, node.ID())
+			return node != b
+		})
+		assertLog(t, ids, "A", "A/B", "A/B[1]")
+	})
+
+	t.Run("paths", func(t *testing.T) {
+		var ids []string
+		for _, path := range b.Paths() {
+			var names []string
+			for _, node := range path {
+				names = append(names, node.Name())
+			}
+			ids = append(ids, strings.Join(names, "->"))
+		}
+		assertLog(t, ids, "A->B->C", "A->B->D")
+
+		if n := len(root.Paths()); n != 3 {
+			t.Errorf("expected tree to have 3 paths, has %d", n)
+		}
+	})
+
+	t.Run("find", func(t *testing.T) {
+		if node := root.Find("A/B[1]"); node != b2 {
+			t.Errorf("expected to find %s by path ID, found %v", b2.ID(), node)
+		}
+		if node := root.Find("D"); node != d {
+			t.Errorf("expected to find %s by name, found %v", d.ID(), node)
+		}
+		if node := root.Find("B"); node != b {
+			t.Errorf("expected to find first node named B, found %v", node)
+		}
+		if node := b2.Find("C"); node != nil {
+			t.Errorf("expected not to find C under %s, found %s", b2.ID(), node.ID())
+		}
+	})
+}
+
+// cleanup is a test that logs its cleanup, both per run and per subtree.
+type cleanup struct {
+	name string
+	log  *[]string
+}
+
+func (c *cleanup) String() string { return c.name }
+
+func (c *cleanup) Run(t *testing.T) {}
+
+func (c *cleanup) After(t *testing.T) { *c.log = append(*c.log, "after "+c.name) }
+
+func (c *cleanup) AfterAll(t testing.TB) { *c.log = append(*c.log, "after all "+c.name) }
+
+func TestAfterAll(t *testing.T) {
+	var log []string
+	root := New(&cleanup{name: "A", log: &log})
+	b := root.Child(&cleanup{name: "B", log: &log})
+	b.Child(&cleanup{name: "C", log: &log})
+	root.Child(&cleanup{name: "D", log: &log})
+	new(runner).run(testT{t}, root)
+
+	assertLog(t, log,
+		"after A",
+		"after B", "after A",
+		"after C", "after B", "after A", "after all C",
+		"after all B",
+		"after D", "after A", "after all D",
+		"after all A",
+	)
+}
@@ -0,0 +1,68 @@
+package tea
+
+import (
+	"testing"
+)
+
+// buggy is a test that always fails, standing in for a test of a known bug.
+type buggy struct {
+	fixed bool
+	log   *[]string
+}
+
+func (b *buggy) Run(t *testing.T) { b.RunTB(t) }
+
+func (b *buggy) RunTB(t testing.TB) {
+	*b.log = append(*b.log, "buggy")
+	if !b.fixed {
+		t.Error("known bug")
+	}
+}
+
+func TestXFail(t *testing.T) {
+	t.Run("expected failure skips children", func(t *testing.T) {
+		var log []string
+		root := New(&record{name: "A", log: &log})
+		root.Child(&buggy{log: &log}).XFail("known bug").
+			Child(&record{name: "B", log: &log})
+		root.Child(&record{name: "C", log: &log})
+
+		new(runner).run(testT{t}, root)
+		assertLog(t, log, "A", "A", "buggy", "A", "C")
+	})
+
+	t.Run("continue after expected failure", func(t *testing.T) {
+		var log []string
+		root := New(&record{name: "A", log: &log})
+		root.Child(&buggy{log: &log}).XFailContinue("known bug").
+			Child(&record{name: "B", log: &log})
+
+		new(runner).run(testT{t}, root)
+		assertLog(t, log, "A", "A", "buggy", "A", "buggy", "B")
+	})
+
+	t.Run("unexpected pass fails", func(t *testing.T) {
+		var log []string
+		node := New(&buggy{fixed: true, log: &log}).XFail("known bug")
+		rec := capture(t, func(t testing.TB) {
+			node.xfail.run(t, node, &buggy{fixed: true, log: &log})
+		})
+		if !rec.Failed() {
+			t.Errorf("expected an unexpected pass to fail")
+		}
+	})
+
+	t.Run("xfail requires TB", func(t *testing.T) {
+		var log []string
+		node := New(&record{name: "A", log: &log}).XFail("known bug")
+		rec := capture(t, func(t testing.TB) {
+			node.xfail.run(t, node, &record{name: "A", log: &log})
+		})
+		if !rec.Failed() {
+			t.Errorf("expected xfail on a test not implementing TB to fail")
+		}
+		if len(log) != 0 {
+			t.Errorf("expected test not to be run, saw %v", log)
+		}
+	})
+}